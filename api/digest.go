@@ -0,0 +1,23 @@
+package api
+
+type DigestAlgorithm string
+
+const (
+	DigestSha256 DigestAlgorithm = "sha256"
+	DigestSha512                 = "sha512"
+	DigestBlake3                 = "blake3"
+)
+
+var DigestAlgorithmAll = []DigestAlgorithm{
+	DigestSha256,
+	DigestSha512,
+	DigestBlake3,
+}
+
+func (a DigestAlgorithm) String() string {
+	return a.Name()
+}
+
+func (a DigestAlgorithm) Name() string {
+	return string(a)
+}
@@ -2,16 +2,31 @@ package api
 
 type ArchiveFormat string
 
+// EncryptedFileExtension is appended to the filename of archives encrypted
+// using age (https://age-encryption.org/).
+const EncryptedFileExtension = ".age"
+
+// EncryptedContentType is the content type of encrypted archives.
+const EncryptedContentType = "application/octet-stream"
+
 const (
-	ArchiveTar     ArchiveFormat = "tar"
-	ArchiveTarGzip               = "tgz"
-	ArchiveTarZstd               = "tzst"
+	ArchiveTar      ArchiveFormat = "tar"
+	ArchiveTarGzip                = "tgz"
+	ArchiveTarZstd                = "tzst"
+	ArchiveTarXz                  = "txz"
+	ArchiveTarLz4                 = "tlz4"
+	ArchiveTarBzip2               = "tbz2"
+	ArchiveZip                    = "zip"
 )
 
 var ArchiveFormatAll = []ArchiveFormat{
 	ArchiveTar,
 	ArchiveTarGzip,
 	ArchiveTarZstd,
+	ArchiveTarXz,
+	ArchiveTarLz4,
+	ArchiveTarBzip2,
+	ArchiveZip,
 }
 
 func (f ArchiveFormat) String() string {
@@ -34,6 +49,18 @@ func (f ArchiveFormat) ContentType() string {
 	case ArchiveTarZstd:
 		// https://www.rfc-editor.org/rfc/rfc8878
 		return "application/zstd"
+
+	case ArchiveTarXz:
+		return "application/x-xz"
+
+	case ArchiveTarLz4:
+		return "application/x-lz4"
+
+	case ArchiveTarBzip2:
+		return "application/x-bzip2"
+
+	case ArchiveZip:
+		return "application/zip"
 	}
 
 	return "application/octet-stream"
@@ -49,6 +76,18 @@ func (f ArchiveFormat) FileExtension() string {
 
 	case ArchiveTarZstd:
 		return ".tar.zst"
+
+	case ArchiveTarXz:
+		return ".tar.xz"
+
+	case ArchiveTarLz4:
+		return ".tar.lz4"
+
+	case ArchiveTarBzip2:
+		return ".tar.bz2"
+
+	case ArchiveZip:
+		return ".zip"
 	}
 
 	return ".bin"
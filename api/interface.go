@@ -18,6 +18,69 @@ type SnapshotResult struct {
 	Name string `json:"name"`
 }
 
+// ListSnapshotsOptions are the options available when listing snapshots.
+type ListSnapshotsOptions struct {
+}
+
+// SnapshotInfo describes a snapshot present on the server.
+type SnapshotInfo struct {
+	// Snapshot name.
+	Name string `json:"name"`
+
+	// Creation time as encoded in the snapshot name. Zero if the name doesn't
+	// contain a timestamp.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Total size of all regular files in bytes. Snapshots consist of hard
+	// links and the size doesn't necessarily reflect the space consumed.
+	TotalSize int64 `json:"total_size"`
+
+	// Number of TSDB blocks in the snapshot.
+	BlockCount int `json:"block_count"`
+
+	// Whether the snapshot is protected from pruning.
+	Pinned bool `json:"pinned"`
+}
+
+// ListSnapshotsResult contains all snapshots present on the server.
+type ListSnapshotsResult struct {
+	// Snapshots sorted by name.
+	Snapshots []SnapshotInfo `json:"snapshots"`
+}
+
+// DeleteSnapshotOptions are the options available when deleting a single
+// snapshot.
+type DeleteSnapshotOptions struct {
+	// Snapshot name.
+	Name string `json:"name"`
+}
+
+// DeleteSnapshotResult may be used in the future.
+type DeleteSnapshotResult struct {
+}
+
+// PinSnapshotOptions are the options available when protecting a snapshot
+// from pruning.
+type PinSnapshotOptions struct {
+	// Snapshot name.
+	Name string `json:"name"`
+}
+
+// PinSnapshotResult may be used in the future.
+type PinSnapshotResult struct {
+}
+
+// UnpinSnapshotOptions are the options available when removing the
+// protection of a snapshot.
+type UnpinSnapshotOptions struct {
+	// Snapshot name.
+	Name string `json:"name"`
+}
+
+// UnpinSnapshotResult may be used in the future.
+type UnpinSnapshotResult struct {
+}
+
 // DownloadOptions are the options available when requesting the download of
 // a snapshot archive.
 type DownloadOptions struct {
@@ -27,6 +90,25 @@ type DownloadOptions struct {
 	// Requested archive format.
 	Format ArchiveFormat
 
+	// Compression level. The valid range depends on the archive format. Zero
+	// selects the default.
+	CompressionLevel int
+
+	// Zstandard window size in bytes. Zero selects the default.
+	CompressionWindow int
+
+	// Number of goroutines used by the gzip and Zstandard encoders. Zero
+	// selects the default.
+	CompressionConcurrency int
+
+	// Public keys of age X25519 recipients (e.g. "age1..."). If any are given
+	// or the server has recipients configured the archive is encrypted.
+	Recipients []string
+
+	// Additional digest algorithms to compute over the archive. SHA256 is
+	// always computed.
+	Digests []DigestAlgorithm
+
 	// Function returning a writer for storing the body returned by the server.
 	BodyWriter func(DownloadResult) (io.Writer, error)
 }
@@ -61,10 +143,54 @@ type DownloadStatus struct {
 	// Requested snapshot name.
 	SnapshotName string `json:"snapshot_name"`
 
+	// Requested archive format.
+	Format ArchiveFormat `json:"format,omitempty"`
+
+	// Time at which the download was requested.
+	StartedAt time.Time `json:"started_at"`
+
+	// Network address of the client which requested the download.
+	ClientAddress string `json:"client_address,omitempty"`
+
+	// User agent of the client which requested the download.
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// Whether the archive is encrypted. The SHA256 checksum is computed over
+	// the encrypted data.
+	Encrypted bool `json:"encrypted,omitempty"`
+
+	// Progress is non-nil once the server started generating the archive.
+	Progress *DownloadProgress `json:"progress,omitempty"`
+
 	// Finished is non-nil if the server consider the download finished.
 	Finished *DownloadStatusFinished `json:"finished"`
 }
 
+// DownloadProgress reports how far the server got in generating the snapshot
+// archive.
+type DownloadProgress struct {
+	// Time at which the server started generating the archive.
+	StartedAt time.Time `json:"started_at"`
+
+	// Number of bytes read from snapshot files.
+	BytesRead int64 `json:"bytes_read"`
+
+	// Total size of all snapshot files in bytes. Zero if unknown.
+	BytesTotal int64 `json:"bytes_total"`
+
+	// Number of bytes written to the client after compression.
+	BytesWritten int64 `json:"bytes_written"`
+
+	// Number of snapshot files already archived.
+	FilesDone int64 `json:"files_done"`
+
+	// Total number of snapshot files. Zero if unknown.
+	FilesTotal int64 `json:"files_total"`
+
+	// Path of the file currently being archived.
+	CurrentFile string `json:"current_file"`
+}
+
 // DownloadStatusFinished contains information about a finished download.
 type DownloadStatusFinished struct {
 	// Success is true if the server encountered no error while generating the
@@ -75,24 +201,140 @@ type DownloadStatusFinished struct {
 	// encountered an error.
 	ErrorText *string `json:"error_text"`
 
+	// Cancelled is true if the download was stopped on request of an
+	// operator.
+	Cancelled bool `json:"cancelled,omitempty"`
+
+	// ClientAborted is true if the client went away before the download
+	// completed, e.g. by closing the connection.
+	ClientAborted bool `json:"client_aborted,omitempty"`
+
 	// Sha256Hex is the result of the SHA256 algorithm over the downloaded
 	// archive.
 	Sha256Hex string `json:"sha256_hex"`
+
+	// Hex-encoded digests over the downloaded archive, keyed by algorithm.
+	// Contains SHA256 and all algorithms requested by the client.
+	Digests map[DigestAlgorithm]string `json:"digests,omitempty"`
+
+	// Ed25519 signature over the SHA256 digest (the 32 raw bytes, not the hex
+	// representation), hex-encoded. Only set if the server has a signing key.
+	SignatureHex string `json:"signature_hex,omitempty"`
+
+	// Time at which the server finished generating the archive.
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// CancelDownloadOptions are the options available when cancelling a running
+// download.
+type CancelDownloadOptions struct {
+	// Unique download ID.
+	ID string `json:"id"`
+}
+
+// CancelDownloadResult may be used in the future.
+type CancelDownloadResult struct {
+}
+
+// ListDownloadsOptions are the options available when listing downloads.
+type ListDownloadsOptions struct {
+}
+
+// ListDownloadsResult contains the status of all downloads tracked by the
+// server.
+type ListDownloadsResult struct {
+	// Downloads sorted by ID, oldest first.
+	Downloads []DownloadStatus `json:"downloads"`
+}
+
+// SigningKeyOptions are the options available when requesting the public key
+// used to sign downloads.
+type SigningKeyOptions struct {
+}
+
+// SigningKeyResult contains the public key used by the server to sign
+// downloads.
+type SigningKeyResult struct {
+	// Ed25519 public key, hex-encoded.
+	PublicKeyHex string `json:"public_key_hex"`
 }
 
 // PruneOptions are the options available when requesting pruning of snapshots.
 type PruneOptions struct {
 	// Keep all snapshots within this time interval.
 	KeepWithin time.Duration `json:"keep_within"`
+
+	// Keep the given number of most recent snapshots.
+	KeepLast int `json:"keep_last"`
+
+	// Keep the most recent snapshot for the given number of distinct hours,
+	// days, ISO weeks and months respectively.
+	KeepHourly  int `json:"keep_hourly"`
+	KeepDaily   int `json:"keep_daily"`
+	KeepWeekly  int `json:"keep_weekly"`
+	KeepMonthly int `json:"keep_monthly"`
+
+	// Remove the oldest snapshots kept by the retention rules until the
+	// snapshot filesystem has at least the given number of bytes or
+	// percentage of its capacity available. Zero disables the respective
+	// threshold. At least MinKeep snapshots are always kept.
+	MinFreeBytes   uint64  `json:"min_free_bytes"`
+	MinFreePercent float64 `json:"min_free_percent"`
+	MinKeep        int     `json:"min_keep"`
+
+	// Report what would be removed without deleting anything.
+	DryRun bool `json:"dry_run"`
 }
 
-// PruneResult may be used in the future.
+// PruneSnapshot describes what happened to a snapshot during pruning.
+type PruneSnapshot struct {
+	// Snapshot name.
+	Name string `json:"name"`
+
+	// Human-readable explanation.
+	Reason string `json:"reason,omitempty"`
+}
+
+// PruneResult reports the outcome of pruning snapshots.
 type PruneResult struct {
+	// DryRun is true when nothing was actually removed.
+	DryRun bool `json:"dry_run"`
+
+	// Snapshots which were removed (or would have been in a dry run).
+	Removed []PruneSnapshot `json:"removed"`
+
+	// Snapshots kept by at least one retention rule.
+	Kept []PruneSnapshot `json:"kept"`
+
+	// Snapshots not kept by any rule or selected to free disk space, but
+	// skipped because they're in use.
+	InUse []PruneSnapshot `json:"in_use"`
+
+	// Pinned snapshots. They're never removed and not considered by the
+	// retention rules.
+	Pinned []PruneSnapshot `json:"pinned"`
+
+	// Directories ignored because their name isn't a valid snapshot name.
+	Invalid []PruneSnapshot `json:"invalid"`
+
+	// Number of bytes freed by removing snapshots. Only files without other
+	// hard links (e.g. from the TSDB) are counted.
+	ReclaimedBytes int64 `json:"reclaimed_bytes"`
+
+	// Time taken for pruning.
+	Duration time.Duration `json:"duration"`
 }
 
 type Interface interface {
 	Snapshot(context.Context, SnapshotOptions) (*SnapshotResult, error)
+	ListSnapshots(context.Context, ListSnapshotsOptions) (*ListSnapshotsResult, error)
+	DeleteSnapshot(context.Context, DeleteSnapshotOptions) (*DeleteSnapshotResult, error)
+	PinSnapshot(context.Context, PinSnapshotOptions) (*PinSnapshotResult, error)
+	UnpinSnapshot(context.Context, UnpinSnapshotOptions) (*UnpinSnapshotResult, error)
 	Download(context.Context, DownloadOptions) (*DownloadResult, error)
 	DownloadStatus(context.Context, DownloadStatusOptions) (*DownloadStatus, error)
+	ListDownloads(context.Context, ListDownloadsOptions) (*ListDownloadsResult, error)
+	CancelDownload(context.Context, CancelDownloadOptions) (*CancelDownloadResult, error)
+	SigningKey(context.Context, SigningKeyOptions) (*SigningKeyResult, error)
 	Prune(context.Context, PruneOptions) (*PruneResult, error)
 }
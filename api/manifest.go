@@ -0,0 +1,53 @@
+package api
+
+import "time"
+
+// ManifestName is the name of the file listing the contents of an archive.
+// It's the last entry of every archive and placed in the top-level snapshot
+// directory.
+const ManifestName = "MANIFEST.json"
+
+// ManifestVersion is the version of the manifest format.
+const ManifestVersion = 1
+
+// Manifest lists the contents of a snapshot archive.
+type Manifest struct {
+	// Version of the manifest format.
+	Version int `json:"version"`
+
+	// Snapshot name, also the top-level directory in the archive.
+	SnapshotName string `json:"snapshot_name"`
+
+	// Regular files in archive order. The manifest itself is not included.
+	Files []ManifestFile `json:"files"`
+
+	// TSDB blocks as described by their "meta.json" files.
+	Blocks []ManifestBlock `json:"blocks"`
+}
+
+// ManifestFile describes a single regular file in an archive.
+type ManifestFile struct {
+	// Slash-separated path within the archive, including the snapshot
+	// directory.
+	Path string `json:"path"`
+
+	// Size in bytes.
+	Size int64 `json:"size"`
+
+	// Modification time, truncated to seconds.
+	ModTime time.Time `json:"mod_time"`
+
+	// Hex-encoded SHA256 digest of the file content.
+	Sha256Hex string `json:"sha256_hex"`
+}
+
+// ManifestBlock describes a TSDB block.
+type ManifestBlock struct {
+	// Unique block ID.
+	ULID string `json:"ulid"`
+
+	// Time range covered by the block in milliseconds since the Unix epoch.
+	// The minimum is inclusive, the maximum exclusive.
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+}
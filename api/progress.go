@@ -0,0 +1,29 @@
+package api
+
+import "time"
+
+// Fraction returns the share of snapshot bytes already read, between 0 and 1.
+// The result is only valid if the total size is known.
+func (p *DownloadProgress) Fraction() (float64, bool) {
+	if p == nil || p.BytesTotal <= 0 {
+		return 0, false
+	}
+
+	return min(1, float64(p.BytesRead)/float64(p.BytesTotal)), true
+}
+
+// Remaining estimates the time until all snapshot bytes have been read,
+// assuming the rate observed since the start stays constant.
+func (p *DownloadProgress) Remaining(now time.Time) (time.Duration, bool) {
+	fraction, ok := p.Fraction()
+	if !ok || fraction <= 0 {
+		return 0, false
+	}
+
+	elapsed := now.Sub(p.StartedAt)
+	if elapsed <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(float64(elapsed) * (1 - fraction) / fraction), true
+}
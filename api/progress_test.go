@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDownloadProgressRemaining(t *testing.T) {
+	startedAt := time.Date(2022, 11, 9, 20, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name         string
+		progress     *DownloadProgress
+		now          time.Time
+		wantFraction float64
+		wantOk       bool
+		want         time.Duration
+	}{
+		{name: "nil"},
+		{
+			name: "unknown total",
+			progress: &DownloadProgress{
+				StartedAt: startedAt,
+				BytesRead: 100,
+			},
+			now: startedAt.Add(time.Minute),
+		},
+		{
+			name: "nothing read",
+			progress: &DownloadProgress{
+				StartedAt:  startedAt,
+				BytesTotal: 100,
+			},
+			now: startedAt.Add(time.Minute),
+		},
+		{
+			name: "quarter",
+			progress: &DownloadProgress{
+				StartedAt:  startedAt,
+				BytesRead:  25,
+				BytesTotal: 100,
+			},
+			now:          startedAt.Add(time.Minute),
+			wantFraction: 0.25,
+			wantOk:       true,
+			want:         3 * time.Minute,
+		},
+		{
+			name: "complete",
+			progress: &DownloadProgress{
+				StartedAt:  startedAt,
+				BytesRead:  200,
+				BytesTotal: 100,
+			},
+			now:          startedAt.Add(time.Minute),
+			wantFraction: 1,
+			wantOk:       true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if fraction, _ := tc.progress.Fraction(); fraction != tc.wantFraction {
+				t.Errorf("Fraction() returned %v, want %v", fraction, tc.wantFraction)
+			}
+
+			got, ok := tc.progress.Remaining(tc.now)
+
+			if ok != tc.wantOk || got != tc.want {
+				t.Errorf("Remaining() returned (%v, %t), want (%v, %t)", got, ok, tc.want, tc.wantOk)
+			}
+		})
+	}
+}
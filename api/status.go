@@ -0,0 +1,20 @@
+package api
+
+// State returns a short human-readable description of the download state:
+// "pending", "in progress", "finished", "cancelled", "aborted" or "failed".
+func (s *DownloadStatus) State() string {
+	switch f := s.Finished; {
+	case f != nil && f.Success:
+		return "finished"
+	case f != nil && f.Cancelled:
+		return "cancelled"
+	case f != nil && f.ClientAborted:
+		return "aborted"
+	case f != nil:
+		return "failed"
+	case s.Progress != nil:
+		return "in progress"
+	}
+
+	return "pending"
+}
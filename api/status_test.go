@@ -0,0 +1,47 @@
+package api
+
+import "testing"
+
+func TestDownloadStatusState(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status DownloadStatus
+		want   string
+	}{
+		{name: "pending", want: "pending"},
+		{
+			name:   "in progress",
+			status: DownloadStatus{Progress: &DownloadProgress{}},
+			want:   "in progress",
+		},
+		{
+			name: "finished",
+			status: DownloadStatus{
+				Progress: &DownloadProgress{},
+				Finished: &DownloadStatusFinished{Success: true},
+			},
+			want: "finished",
+		},
+		{
+			name:   "cancelled",
+			status: DownloadStatus{Finished: &DownloadStatusFinished{Cancelled: true}},
+			want:   "cancelled",
+		},
+		{
+			name:   "aborted",
+			status: DownloadStatus{Finished: &DownloadStatusFinished{ClientAborted: true}},
+			want:   "aborted",
+		},
+		{
+			name:   "failed",
+			status: DownloadStatus{Finished: &DownloadStatusFinished{}},
+			want:   "failed",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.status.State(); got != tc.want {
+				t.Errorf("State() returned %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
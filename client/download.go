@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"strconv"
 
 	"github.com/hansmi/prombackup/api"
 	"github.com/hansmi/prombackup/internal/apiendpoints"
@@ -22,6 +23,27 @@ func (h *httpClient) Download(ctx context.Context, opts api.DownloadOptions) (*a
 		queryValues.Set("format", opts.Format.Name())
 	}
 
+	for _, i := range []struct {
+		name  string
+		value int
+	}{
+		{"level", opts.CompressionLevel},
+		{"window", opts.CompressionWindow},
+		{"concurrency", opts.CompressionConcurrency},
+	} {
+		if i.value != 0 {
+			queryValues.Set(i.name, strconv.Itoa(i.value))
+		}
+	}
+
+	for _, recipient := range opts.Recipients {
+		queryValues.Add("recipient", recipient)
+	}
+
+	for _, a := range opts.Digests {
+		queryValues.Add("digest", a.Name())
+	}
+
 	req, err := h.newRequest(ctx, http.MethodGet, h.buildURL(apiendpoints.Download, queryValues))
 	if err != nil {
 		return nil, err
@@ -84,6 +84,84 @@ func TestDownload(t *testing.T) {
 				Filename:    "bar.tgz",
 			},
 		},
+		{
+			name: "with compression",
+			opts: api.DownloadOptions{
+				SnapshotName:           "zstd",
+				Format:                 api.ArchiveTarZstd,
+				CompressionLevel:       19,
+				CompressionWindow:      1 << 20,
+				CompressionConcurrency: 4,
+			},
+			responseCode: http.StatusOK,
+			responseHeader: map[string]string{
+				api.HttpHeaderDownloadID: "9d1e0b1c-3f5a-4c39-9f0e-2b8d6f1c7a44",
+				"Content-Type":           "application/zstd",
+				"Content-Disposition":    "attachment; filename=zstd.tar.zst",
+			},
+			wantQuery: url.Values{
+				"name":        {"zstd"},
+				"format":      {"tzst"},
+				"level":       {"19"},
+				"window":      {"1048576"},
+				"concurrency": {"4"},
+			},
+			want: &api.DownloadResult{
+				ID:          "9d1e0b1c-3f5a-4c39-9f0e-2b8d6f1c7a44",
+				ContentType: "application/zstd",
+				Filename:    "zstd.tar.zst",
+			},
+		},
+		{
+			name: "encrypted",
+			opts: api.DownloadOptions{
+				SnapshotName: "secret",
+				Recipients: []string{
+					"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+					"age1lggyhqrw2nlhcxprm67z43rta597azn8gknawjehu9d9dl0jq3yqqvfafg",
+				},
+			},
+			responseCode: http.StatusOK,
+			responseHeader: map[string]string{
+				api.HttpHeaderDownloadID: "d0b7c4f2-5a7e-4c1d-8b3e-6f2a9c0e1d45",
+				"Content-Type":           "application/octet-stream",
+				"Content-Disposition":    "attachment; filename=secret.tar.age",
+			},
+			wantQuery: url.Values{
+				"name": {"secret"},
+				"recipient": {
+					"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+					"age1lggyhqrw2nlhcxprm67z43rta597azn8gknawjehu9d9dl0jq3yqqvfafg",
+				},
+			},
+			want: &api.DownloadResult{
+				ID:          "d0b7c4f2-5a7e-4c1d-8b3e-6f2a9c0e1d45",
+				ContentType: "application/octet-stream",
+				Filename:    "secret.tar.age",
+			},
+		},
+		{
+			name: "digests",
+			opts: api.DownloadOptions{
+				SnapshotName: "digests",
+				Digests:      []api.DigestAlgorithm{api.DigestSha512, api.DigestBlake3},
+			},
+			responseCode: http.StatusOK,
+			responseHeader: map[string]string{
+				api.HttpHeaderDownloadID: "5e2f8a61-0c3d-4b7e-a1f9-3d6c8b2e7f10",
+				"Content-Type":           "application/x-tar",
+				"Content-Disposition":    "attachment; filename=digests.tar",
+			},
+			wantQuery: url.Values{
+				"name":   {"digests"},
+				"digest": {"sha512", "blake3"},
+			},
+			want: &api.DownloadResult{
+				ID:          "5e2f8a61-0c3d-4b7e-a1f9-3d6c8b2e7f10",
+				ContentType: "application/x-tar",
+				Filename:    "digests.tar",
+			},
+		},
 		{
 			name: "missing content-disposition",
 			opts: api.DownloadOptions{
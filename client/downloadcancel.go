@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func (h *httpClient) CancelDownload(ctx context.Context, opts api.CancelDownloadOptions) (*api.CancelDownloadResult, error) {
+	u := h.buildURL(apiendpoints.DownloadCancel, url.Values{
+		"id": {opts.ID},
+	})
+
+	req, err := h.newRequest(ctx, http.MethodPost, u)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.doReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var result api.CancelDownloadResult
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, errorFromResponse(resp)
+	}
+
+	return &result, nil
+}
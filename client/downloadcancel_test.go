@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func TestCancelDownload(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		opts         api.CancelDownloadOptions
+		responseCode int
+		response     string
+		wantQuery    url.Values
+		wantErr      error
+		want         *api.CancelDownloadResult
+	}{
+		{
+			name: "success",
+			opts: api.CancelDownloadOptions{
+				ID: "20221109202035_1a3f8c9d2",
+			},
+			responseCode: http.StatusOK,
+			response:     `{}`,
+			wantQuery: url.Values{
+				"id": {"20221109202035_1a3f8c9d2"},
+			},
+			want: &api.CancelDownloadResult{},
+		},
+		{
+			name: "finished",
+			opts: api.CancelDownloadOptions{
+				ID: "20221109202035_1a3f8c9d2",
+			},
+			responseCode: http.StatusConflict,
+			wantQuery: url.Values{
+				"id": {"20221109202035_1a3f8c9d2"},
+			},
+			wantErr: ErrRequestFailed,
+		},
+		{
+			name:         "error",
+			responseCode: http.StatusNotFound,
+			wantQuery: url.Values{
+				"id": {""},
+			},
+			wantErr: ErrRequestFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := fakeServer{
+				method:       http.MethodPost,
+				path:         apiendpoints.DownloadCancel,
+				wantQuery:    tc.wantQuery,
+				responseCode: tc.responseCode,
+				responseBody: tc.response,
+			}.start(t)
+
+			c := newTestClient(t, ts)
+
+			response, err := c.CancelDownload(context.Background(), tc.opts)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, response); diff != "" {
+				t.Errorf("Response diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func TestListDownloads(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		opts         api.ListDownloadsOptions
+		responseCode int
+		response     string
+		wantErr      error
+		want         *api.ListDownloadsResult
+	}{
+		{
+			name:         "empty",
+			responseCode: http.StatusOK,
+			response:     `{}`,
+			want:         &api.ListDownloadsResult{},
+		},
+		{
+			name:         "downloads",
+			responseCode: http.StatusOK,
+			response: `{ "downloads": [
+				{
+					"id": "20221109202035_1a3f8c9d2",
+					"snapshot_name": "20221109T202035Z-355a5b4970d5a906",
+					"format": "tzst",
+					"started_at": "2022-11-09T20:20:35Z",
+					"client_address": "192.0.2.1:1234",
+					"user_agent": "test/1.0",
+					"finished": { "success": true, "finished_at": "2022-11-09T20:30:00Z" }
+				}
+			] }`,
+			want: &api.ListDownloadsResult{
+				Downloads: []api.DownloadStatus{
+					{
+						ID:            "20221109202035_1a3f8c9d2",
+						SnapshotName:  "20221109T202035Z-355a5b4970d5a906",
+						Format:        api.ArchiveTarZstd,
+						StartedAt:     time.Date(2022, 11, 9, 20, 20, 35, 0, time.UTC),
+						ClientAddress: "192.0.2.1:1234",
+						UserAgent:     "test/1.0",
+						Finished: &api.DownloadStatusFinished{
+							Success:    true,
+							FinishedAt: time.Date(2022, 11, 9, 20, 30, 0, 0, time.UTC),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:         "error",
+			responseCode: http.StatusInternalServerError,
+			wantErr:      ErrRequestFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := fakeServer{
+				method:       http.MethodGet,
+				path:         apiendpoints.Downloads,
+				responseCode: tc.responseCode,
+				responseBody: tc.response,
+			}.start(t)
+
+			c := newTestClient(t, ts)
+
+			response, err := c.ListDownloads(context.Background(), tc.opts)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, response); diff != "" {
+				t.Errorf("Response diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/hansmi/prombackup/api"
 	"github.com/hansmi/prombackup/internal/apiendpoints"
@@ -18,6 +19,31 @@ func (h *httpClient) Prune(ctx context.Context, opts api.PruneOptions) (*api.Pru
 		queryValues.Set("keep_within", opts.KeepWithin.String())
 	}
 
+	if opts.DryRun {
+		queryValues.Set("dry_run", strconv.FormatBool(opts.DryRun))
+	}
+
+	if opts.MinFreeBytes != 0 {
+		queryValues.Set("min_free_bytes", strconv.FormatUint(opts.MinFreeBytes, 10))
+	}
+
+	if opts.MinFreePercent != 0 {
+		queryValues.Set("min_free_percent", strconv.FormatFloat(opts.MinFreePercent, 'f', -1, 64))
+	}
+
+	for name, value := range map[string]int{
+		"min_keep":     opts.MinKeep,
+		"keep_last":    opts.KeepLast,
+		"keep_hourly":  opts.KeepHourly,
+		"keep_daily":   opts.KeepDaily,
+		"keep_weekly":  opts.KeepWeekly,
+		"keep_monthly": opts.KeepMonthly,
+	} {
+		if value != 0 {
+			queryValues.Set(name, strconv.Itoa(value))
+		}
+	}
+
 	req, err := h.newRequest(ctx, http.MethodPost, h.buildURL(apiendpoints.Prune, queryValues))
 	if err != nil {
 		return nil, err
@@ -34,7 +60,7 @@ func (h *httpClient) Prune(ctx context.Context, opts api.PruneOptions) (*api.Pru
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		body, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
 		if err != nil {
 			return nil, err
 		}
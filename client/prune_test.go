@@ -41,6 +41,72 @@ func TestPrune(t *testing.T) {
 			},
 			want: &api.PruneResult{},
 		},
+		{
+			name:         "retention rules",
+			responseCode: http.StatusOK,
+			response:     `{}`,
+			opts: api.PruneOptions{
+				KeepLast:    3,
+				KeepDaily:   7,
+				KeepWeekly:  4,
+				KeepMonthly: 6,
+			},
+			wantQuery: url.Values{
+				"keep_last":    {"3"},
+				"keep_daily":   {"7"},
+				"keep_weekly":  {"4"},
+				"keep_monthly": {"6"},
+			},
+			want: &api.PruneResult{},
+		},
+		{
+			name:         "free space",
+			responseCode: http.StatusOK,
+			response:     `{}`,
+			opts: api.PruneOptions{
+				MinFreeBytes:   10 * 1024 * 1024 * 1024,
+				MinFreePercent: 12.5,
+				MinKeep:        2,
+			},
+			wantQuery: url.Values{
+				"min_free_bytes":   {"10737418240"},
+				"min_free_percent": {"12.5"},
+				"min_keep":         {"2"},
+			},
+			want: &api.PruneResult{},
+		},
+		{
+			name:         "dry run",
+			responseCode: http.StatusOK,
+			response: `{
+				"dry_run": true,
+				"removed": [{ "name": "old-1", "reason": "not kept by any rule" }],
+				"kept": [{ "name": "new-2", "reason": "last" }],
+				"in_use": [{ "name": "busy-3", "reason": "snapshot in use" }],
+				"invalid": [{ "name": "bad" }]
+			}`,
+			opts: api.PruneOptions{
+				DryRun: true,
+			},
+			wantQuery: url.Values{
+				"dry_run": {"true"},
+			},
+			want: &api.PruneResult{
+				DryRun: true,
+				Removed: []api.PruneSnapshot{
+					{Name: "old-1", Reason: "not kept by any rule"},
+				},
+				Kept: []api.PruneSnapshot{
+					{Name: "new-2", Reason: "last"},
+				},
+				InUse: []api.PruneSnapshot{
+					{Name: "busy-3", Reason: "snapshot in use"},
+				},
+				Invalid: []api.PruneSnapshot{
+					{Name: "bad"},
+				},
+			},
+		},
 		{
 			name:         "error",
 			responseCode: http.StatusNotFound,
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+// ErrNoSigningKey is returned when the server doesn't sign downloads.
+var ErrNoSigningKey = errors.New("no signing key configured")
+
+func (h *httpClient) SigningKey(ctx context.Context, opts api.SigningKeyOptions) (*api.SigningKeyResult, error) {
+	req, err := h.newRequest(ctx, http.MethodGet, h.buildURL(apiendpoints.SigningKey, url.Values{}))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.doReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var result api.SigningKeyResult
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %w", ErrNoSigningKey, errorFromResponse(resp))
+
+	default:
+		return nil, errorFromResponse(resp)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func TestSigningKey(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		responseCode int
+		response     string
+		wantErr      error
+		want         *api.SigningKeyResult
+	}{
+		{
+			name:         "success",
+			responseCode: http.StatusOK,
+			response:     `{"public_key_hex": "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"}`,
+			want: &api.SigningKeyResult{
+				PublicKeyHex: "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+			},
+		},
+		{
+			name:         "no key",
+			responseCode: http.StatusNotFound,
+			wantErr:      ErrNoSigningKey,
+		},
+		{
+			name:         "error",
+			responseCode: http.StatusInternalServerError,
+			wantErr:      ErrRequestFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := fakeServer{
+				method:       http.MethodGet,
+				path:         apiendpoints.SigningKey,
+				responseCode: tc.responseCode,
+				responseBody: tc.response,
+			}.start(t)
+
+			c := newTestClient(t, ts)
+
+			response, err := c.SigningKey(context.Background(), api.SigningKeyOptions{})
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, response); diff != "" {
+				t.Errorf("Response diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
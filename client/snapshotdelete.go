@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+// ErrSnapshotInUse is returned when the server refuses to remove a snapshot
+// because it's still in use, e.g. by a download.
+var ErrSnapshotInUse = errors.New("snapshot in use")
+
+// ErrSnapshotPinned is returned when the server refuses to remove a pinned
+// snapshot.
+var ErrSnapshotPinned = errors.New("snapshot pinned")
+
+func (h *httpClient) DeleteSnapshot(ctx context.Context, opts api.DeleteSnapshotOptions) (*api.DeleteSnapshotResult, error) {
+	u := h.buildURL(apiendpoints.Snapshot, url.Values{
+		"name": {opts.Name},
+	})
+
+	req, err := h.newRequest(ctx, http.MethodDelete, u)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.doReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var result api.DeleteSnapshotResult
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+
+	case http.StatusConflict:
+		return nil, fmt.Errorf("%w: %w", ErrSnapshotInUse, errorFromResponse(resp))
+
+	case http.StatusLocked:
+		return nil, fmt.Errorf("%w: %w", ErrSnapshotPinned, errorFromResponse(resp))
+
+	default:
+		return nil, errorFromResponse(resp)
+	}
+
+	return &result, nil
+}
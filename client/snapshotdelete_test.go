@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func TestDeleteSnapshot(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		opts         api.DeleteSnapshotOptions
+		responseCode int
+		response     string
+		wantQuery    url.Values
+		wantErr      error
+		want         *api.DeleteSnapshotResult
+	}{
+		{
+			name: "success",
+			opts: api.DeleteSnapshotOptions{
+				Name: "20221109T202035Z-355a5b4970d5a906",
+			},
+			responseCode: http.StatusOK,
+			response:     `{}`,
+			wantQuery: url.Values{
+				"name": {"20221109T202035Z-355a5b4970d5a906"},
+			},
+			want: &api.DeleteSnapshotResult{},
+		},
+		{
+			name: "in use",
+			opts: api.DeleteSnapshotOptions{
+				Name: "busy-123",
+			},
+			responseCode: http.StatusConflict,
+			wantQuery: url.Values{
+				"name": {"busy-123"},
+			},
+			wantErr: ErrSnapshotInUse,
+		},
+		{
+			name: "pinned",
+			opts: api.DeleteSnapshotOptions{
+				Name: "pinned-123",
+			},
+			responseCode: http.StatusLocked,
+			wantQuery: url.Values{
+				"name": {"pinned-123"},
+			},
+			wantErr: ErrSnapshotPinned,
+		},
+		{
+			name:         "error",
+			responseCode: http.StatusNotFound,
+			wantQuery: url.Values{
+				"name": {""},
+			},
+			wantErr: ErrRequestFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := fakeServer{
+				method:       http.MethodDelete,
+				path:         apiendpoints.Snapshot,
+				wantQuery:    tc.wantQuery,
+				responseCode: tc.responseCode,
+				responseBody: tc.response,
+			}.start(t)
+
+			c := newTestClient(t, ts)
+
+			response, err := c.DeleteSnapshot(context.Background(), tc.opts)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, response); diff != "" {
+				t.Errorf("Response diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
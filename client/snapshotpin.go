@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func (h *httpClient) changeSnapshotPin(ctx context.Context, endpoint, name string, result any) error {
+	u := h.buildURL(endpoint, url.Values{
+		"name": {name},
+	})
+
+	req, err := h.newRequest(ctx, http.MethodPost, u)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.doReq(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errorFromResponse(resp)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+func (h *httpClient) PinSnapshot(ctx context.Context, opts api.PinSnapshotOptions) (*api.PinSnapshotResult, error) {
+	var result api.PinSnapshotResult
+
+	if err := h.changeSnapshotPin(ctx, apiendpoints.SnapshotPin, opts.Name, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (h *httpClient) UnpinSnapshot(ctx context.Context, opts api.UnpinSnapshotOptions) (*api.UnpinSnapshotResult, error) {
+	var result api.UnpinSnapshotResult
+
+	if err := h.changeSnapshotPin(ctx, apiendpoints.SnapshotUnpin, opts.Name, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
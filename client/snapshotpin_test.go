@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func TestPinSnapshot(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		unpin        bool
+		snapshotName string
+		responseCode int
+		response     string
+		wantErr      error
+		want         any
+	}{
+		{
+			name:         "pin",
+			snapshotName: "20221109T202035Z-355a5b4970d5a906",
+			responseCode: http.StatusOK,
+			response:     `{}`,
+			want:         &api.PinSnapshotResult{},
+		},
+		{
+			name:         "unpin",
+			unpin:        true,
+			snapshotName: "20221109T202035Z-355a5b4970d5a906",
+			responseCode: http.StatusOK,
+			response:     `{}`,
+			want:         &api.UnpinSnapshotResult{},
+		},
+		{
+			name:         "pin error",
+			snapshotName: "missing-123",
+			responseCode: http.StatusNotFound,
+			wantErr:      ErrRequestFailed,
+			want:         (*api.PinSnapshotResult)(nil),
+		},
+		{
+			name:         "unpin error",
+			unpin:        true,
+			responseCode: http.StatusNotFound,
+			wantErr:      ErrRequestFailed,
+			want:         (*api.UnpinSnapshotResult)(nil),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := apiendpoints.SnapshotPin
+
+			if tc.unpin {
+				path = apiendpoints.SnapshotUnpin
+			}
+
+			ts := fakeServer{
+				method: http.MethodPost,
+				path:   path,
+				wantQuery: url.Values{
+					"name": {tc.snapshotName},
+				},
+				responseCode: tc.responseCode,
+				responseBody: tc.response,
+			}.start(t)
+
+			c := newTestClient(t, ts)
+
+			var response any
+			var err error
+
+			if tc.unpin {
+				response, err = c.UnpinSnapshot(context.Background(), api.UnpinSnapshotOptions{
+					Name: tc.snapshotName,
+				})
+			} else {
+				response, err = c.PinSnapshot(context.Background(), api.PinSnapshotOptions{
+					Name: tc.snapshotName,
+				})
+			}
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, response); diff != "" {
+				t.Errorf("Response diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func (h *httpClient) ListSnapshots(ctx context.Context, opts api.ListSnapshotsOptions) (*api.ListSnapshotsResult, error) {
+	req, err := h.newRequest(ctx, http.MethodGet, h.buildURL(apiendpoints.Snapshots, url.Values{}))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.doReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var result api.ListSnapshotsResult
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, errorFromResponse(resp)
+	}
+
+	return &result, nil
+}
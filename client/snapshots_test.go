@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func TestListSnapshots(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		opts         api.ListSnapshotsOptions
+		responseCode int
+		response     string
+		wantErr      error
+		want         *api.ListSnapshotsResult
+	}{
+		{
+			name:         "empty",
+			responseCode: http.StatusOK,
+			response:     `{}`,
+			want:         &api.ListSnapshotsResult{},
+		},
+		{
+			name:         "snapshots",
+			responseCode: http.StatusOK,
+			response: `{ "snapshots": [
+				{ "name": "20221109T202035Z-355a5b4970d5a906", "created_at": "2022-11-09T20:20:35Z", "total_size": 1234, "block_count": 3 },
+				{ "name": "20221110T101010Z-0a1b2c3d4e5f6789" }
+			] }`,
+			want: &api.ListSnapshotsResult{
+				Snapshots: []api.SnapshotInfo{
+					{
+						Name:       "20221109T202035Z-355a5b4970d5a906",
+						CreatedAt:  time.Date(2022, 11, 9, 20, 20, 35, 0, time.UTC),
+						TotalSize:  1234,
+						BlockCount: 3,
+					},
+					{
+						Name: "20221110T101010Z-0a1b2c3d4e5f6789",
+					},
+				},
+			},
+		},
+		{
+			name:         "error",
+			responseCode: http.StatusInternalServerError,
+			wantErr:      ErrRequestFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := fakeServer{
+				method:       http.MethodGet,
+				path:         apiendpoints.Snapshots,
+				responseCode: tc.responseCode,
+				responseBody: tc.response,
+			}.start(t)
+
+			c := newTestClient(t, ts)
+
+			response, err := c.ListSnapshots(context.Background(), tc.opts)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, response); diff != "" {
+				t.Errorf("Response diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -26,7 +26,7 @@ func (p *autopruner) run(ctx context.Context) {
 			return
 		}
 
-		if err := pruner.Prune(ctx, p.opts); err != nil {
+		if _, err := pruner.Prune(ctx, p.opts); err != nil {
 			p.opts.Logger.Printf("Pruning failed: %v", err)
 		}
 
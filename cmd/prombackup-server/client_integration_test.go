@@ -47,12 +47,38 @@ func TestClientIntegration(t *testing.T) {
 		t.Errorf("Snapshot() diff (-want +got):\n%s", diff)
 	}
 
+	if result, err := c.ListSnapshots(context.Background(), api.ListSnapshotsOptions{}); err != nil {
+		t.Errorf("ListSnapshots() failed: %v", err)
+	} else if diff := cmp.Diff(&api.ListSnapshotsResult{
+		Snapshots: []api.SnapshotInfo{},
+	}, result); diff != "" {
+		t.Errorf("ListSnapshots() diff (-want +got):\n%s", diff)
+	}
+
+	if _, err := c.DeleteSnapshot(context.Background(), api.DeleteSnapshotOptions{
+		Name: "20221110T101010Z-0a1b2c3d4e5f6789",
+	}); !(errors.Is(err, client.ErrRequestFailed) && strings.Contains(err.Error(), "no such file")) {
+		t.Errorf("DeleteSnapshot() failed: %v", err)
+	}
+
+	if _, err := c.PinSnapshot(context.Background(), api.PinSnapshotOptions{
+		Name: "20221110T101010Z-0a1b2c3d4e5f6789",
+	}); !(errors.Is(err, client.ErrRequestFailed) && strings.Contains(err.Error(), "no such file")) {
+		t.Errorf("PinSnapshot() failed: %v", err)
+	}
+
 	if _, err := c.Download(context.Background(), api.DownloadOptions{
 		SnapshotName: "snapname-123",
 	}); !(errors.Is(err, client.ErrRequestFailed) && strings.Contains(err.Error(), "snapshot not found:")) {
 		t.Errorf("Download() failed: %v", err)
 	}
 
+	if got, err := c.ListDownloads(context.Background(), api.ListDownloadsOptions{}); err != nil {
+		t.Errorf("ListDownloads() failed: %v", err)
+	} else if len(got.Downloads) != 0 {
+		t.Errorf("ListDownloads() returned downloads: %+v", got.Downloads)
+	}
+
 	if _, err := c.DownloadStatus(context.Background(), api.DownloadStatusOptions{
 		ID: "5a21f075-fc46-43db-af80-83a43a9383db",
 	}); !(errors.Is(err, client.ErrRequestFailed) && strings.Contains(err.Error(), "Download 5a21f075-fc46-43db-af80-83a43a9383db not found")) {
@@ -2,11 +2,16 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io/fs"
 	"mime"
 	"net/http"
+	"slices"
+	"strconv"
 
+	"filippo.io/age"
 	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/digest"
 	"github.com/hansmi/prombackup/internal/snapshotstream"
 )
 
@@ -33,6 +38,51 @@ func (m *manager) handleDownload(w http.ResponseWriter, r *http.Request) {
 		format = api.ArchiveFormat(rawFormat)
 	}
 
+	var compression snapshotstream.CompressionOptions
+
+	for _, i := range []struct {
+		name  string
+		value *int
+		limit int
+	}{
+		{"level", &compression.Level, m.compressionLimits.Level},
+		{"window", &compression.Window, m.compressionLimits.Window},
+		{"concurrency", &compression.Concurrency, m.compressionLimits.Concurrency},
+	} {
+		if raw := q.Get(i.name); raw != "" {
+			if value, err := strconv.Atoi(raw); err != nil {
+				http.Error(w, fmt.Sprintf("Parsing %s: %v", i.name, err.Error()), http.StatusBadRequest)
+				return
+			} else if value < 0 {
+				http.Error(w, fmt.Sprintf("Parsing %s: value must not be negative", i.name), http.StatusBadRequest)
+				return
+			} else if i.limit > 0 && value > i.limit {
+				http.Error(w, fmt.Sprintf("Parsing %s: value must not exceed %d", i.name, i.limit), http.StatusBadRequest)
+				return
+			} else {
+				*i.value = value
+			}
+		}
+	}
+
+	recipients := slices.Clone(m.recipients)
+
+	for _, raw := range q["recipient"] {
+		recipient, err := age.ParseX25519Recipient(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Parsing recipient: %v", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	var digests []api.DigestAlgorithm
+
+	for _, raw := range q["digest"] {
+		digests = append(digests, api.DigestAlgorithm(raw))
+	}
+
 	dir, err := fs.Sub(m.snapshotRoot, name)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
@@ -40,15 +90,25 @@ func (m *manager) handleDownload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s, err := snapshotstream.New(snapshotstream.Options{
-		Name:   name,
-		Root:   dir,
-		Format: format,
+		Name:        name,
+		Root:        dir,
+		Format:      format,
+		Compression: compression,
+		Recipients:  recipients,
+		SigningKey:  m.signingKey,
+		Digests:     digests,
+
+		DefaultConcurrency: m.compressionLimits.Concurrency,
+
+		ClientAddress: r.RemoteAddr,
+		UserAgent:     r.UserAgent(),
 	})
 
 	if err != nil {
 		code := http.StatusInternalServerError
 
-		if errors.Is(err, snapshotstream.ErrArchiveFormat) {
+		if errors.Is(err, snapshotstream.ErrArchiveFormat) || errors.Is(err, snapshotstream.ErrCompressionOptions) ||
+			errors.Is(err, digest.ErrUnknownAlgorithm) {
 			code = http.StatusBadRequest
 		} else if errors.Is(err, snapshotstream.ErrNotFound) || errors.Is(err, snapshotstream.ErrInvalid) {
 			code = http.StatusNotFound
@@ -60,9 +120,7 @@ func (m *manager) handleDownload(w http.ResponseWriter, r *http.Request) {
 
 	id := s.ID()
 
-	m.mu.Lock()
-	m.downloads[id] = s
-	m.mu.Unlock()
+	m.addDownload(s)
 
 	defer m.deleteDownloadAfter(id, m.downloadLifetime)
 
@@ -73,9 +131,17 @@ func (m *manager) handleDownload(w http.ResponseWriter, r *http.Request) {
 	}))
 	header.Set(api.HttpHeaderDownloadID, id)
 
-	if err := s.WriteArchive(w); err != nil {
+	if err := s.WriteArchive(r.Context(), w); errors.Is(err, snapshotstream.ErrClientAborted) {
+		m.logger.Printf("Download %s aborted by client: %v", id, err)
+	} else if err != nil {
 		m.logger.Printf("Download %s failed: %v", id, err)
 	} else {
 		m.logger.Printf("Download %s finished: %+v", id, s.Status().Finished)
 	}
+
+	if err := m.statusStore.Put(s.Status()); err != nil {
+		m.logger.Printf("Storing status of download %s failed: %v", id, err)
+	}
+
+	m.markDownloadFinished(id)
 }
@@ -12,8 +12,10 @@ import (
 	"regexp"
 	"testing"
 
+	"filippo.io/age"
 	"github.com/hansmi/prombackup/api"
 	"github.com/hansmi/prombackup/internal/apiendpoints"
+	"github.com/hansmi/prombackup/internal/snapshotstream"
 )
 
 func TestDownload(t *testing.T) {
@@ -69,10 +71,75 @@ func TestDownload(t *testing.T) {
 			wantCode:   http.StatusBadRequest,
 			wantBodyRe: regexp.MustCompile(`(?i)^Invalid snapshot name\b`),
 		},
+		{
+			name: "bad level",
+			target: url.URL{
+				Path:     apiendpoints.Download,
+				RawQuery: "name=20221109T202035Z-355a5b4970d5a906&format=tgz&level=high",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing level:`),
+		},
+		{
+			name: "level unsupported by format",
+			target: url.URL{
+				Path:     apiendpoints.Download,
+				RawQuery: "name=20221109T202035Z-355a5b4970d5a906&level=3",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^invalid compression options\b`),
+		},
+		{
+			name: "window exceeds limit",
+			target: url.URL{
+				Path:     apiendpoints.Download,
+				RawQuery: "name=20221109T202035Z-355a5b4970d5a906&format=tzst&window=2097152",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing window: value must not exceed 1048576\b`),
+		},
+		{
+			name: "bad recipient",
+			target: url.URL{
+				Path:     apiendpoints.Download,
+				RawQuery: "name=20221109T202035Z-355a5b4970d5a906&recipient=age1invalid",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing recipient:`),
+		},
+		{
+			name: "additional digests",
+			target: url.URL{
+				Path:     apiendpoints.Download,
+				RawQuery: "name=20221109T202035Z-355a5b4970d5a906&digest=sha512&digest=blake3",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "unknown digest",
+			target: url.URL{
+				Path:     apiendpoints.Download,
+				RawQuery: "name=20221109T202035Z-355a5b4970d5a906&digest=md5",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^unknown digest algorithm\b`),
+		},
+		{
+			name: "negative concurrency",
+			target: url.URL{
+				Path:     apiendpoints.Download,
+				RawQuery: "name=20221109T202035Z-355a5b4970d5a906&format=tzst&concurrency=-1",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing concurrency: value must not be negative\b`),
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			m, err := newManager(managerOptions{
 				snapshotDir: tmpdir,
+				compressionLimits: snapshotstream.CompressionOptions{
+					Window: 1024 * 1024,
+				},
 			})
 			if err != nil {
 				t.Fatalf("newManager() failed: %v", err)
@@ -104,7 +171,69 @@ func TestDownload(t *testing.T) {
 				if count < 1 {
 					t.Error("Tar archive is empty")
 				}
+
+				id := resp.Header.Get(api.HttpHeaderDownloadID)
+
+				if status, err := m.statusStore.Get(id); err != nil {
+					t.Errorf("Status of download %s not stored: %v", id, err)
+				} else if status.Finished == nil || !status.Finished.Success {
+					t.Errorf("Stored status of download %s is not successful: %+v", id, status)
+				}
 			}
 		})
 	}
 }
+
+func TestDownloadEncrypted(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	if err := os.Mkdir(filepath.Join(tmpdir, "20221109T202035Z-355a5b4970d5a906"), 0o700); err != nil {
+		t.Error(err)
+	}
+
+	serverIdentity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientIdentity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := newManager(managerOptions{
+		snapshotDir: tmpdir,
+		recipients:  []age.Recipient{serverIdentity.Recipient()},
+	})
+	if err != nil {
+		t.Fatalf("newManager() failed: %v", err)
+	}
+
+	_, body := handlerTest{
+		handler: newRouter(m, nil),
+		target: url.URL{
+			Path: apiendpoints.Download,
+			RawQuery: url.Values{
+				"name":      {"20221109T202035Z-355a5b4970d5a906"},
+				"recipient": {clientIdentity.Recipient().String()},
+			}.Encode(),
+		},
+		wantStatusCode: http.StatusOK,
+		wantHeaderMatch: map[string]*regexp.Regexp{
+			"Content-Type":        regexp.MustCompile(`(?i)^application/octet-stream\b`),
+			"Content-Disposition": regexp.MustCompile(`(?i)\bfilename.*=.*a906\.tar\.age\b`),
+		},
+	}.do(t)
+
+	for _, identity := range []age.Identity{serverIdentity, clientIdentity} {
+		dr, err := age.Decrypt(bytes.NewReader(body), identity)
+		if err != nil {
+			t.Errorf("Decrypt() failed: %v", err)
+			continue
+		}
+
+		if _, err := tar.NewReader(dr).Next(); err != nil {
+			t.Errorf("Invalid tar archive: %v", err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+func (m *manager) handleCancelDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Download ID is required", http.StatusNotFound)
+		return
+	}
+
+	m.mu.Lock()
+	s := m.downloads[id]
+	m.mu.Unlock()
+
+	if s == nil {
+		http.Error(w, fmt.Sprintf("Download %s not found", id), http.StatusNotFound)
+		return
+	}
+
+	if !s.Cancel() {
+		http.Error(w, fmt.Sprintf("Download %s has already finished", id), http.StatusConflict)
+		return
+	}
+
+	m.logger.Printf("Download %s cancelled", id)
+
+	writeJsonResponse(w, http.StatusOK, nil, api.CancelDownloadResult{})
+}
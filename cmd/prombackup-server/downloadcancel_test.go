@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+	"github.com/hansmi/prombackup/internal/snapshotstream"
+)
+
+func TestCancelDownload(t *testing.T) {
+	m, err := newManager(managerOptions{
+		snapshotDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("newManager() failed: %v", err)
+	}
+
+	newStream := func(name string) *snapshotstream.Stream {
+		s, err := snapshotstream.New(snapshotstream.Options{
+			Name:   name,
+			Root:   &fstest.MapFS{},
+			Format: api.ArchiveTar,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m.addDownload(s)
+
+		return s
+	}
+
+	pending := newStream("pending-1")
+	finished := newStream("finished-2")
+
+	if err := finished.WriteArchive(context.Background(), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		target     url.URL
+		wantCode   int
+		wantBodyRe *regexp.Regexp
+		want       *api.CancelDownloadResult
+	}{
+		{
+			name: "success",
+			target: url.URL{
+				Path:     apiendpoints.DownloadCancel,
+				RawQuery: "id=" + url.QueryEscape(pending.ID()),
+			},
+			wantCode: http.StatusOK,
+			want:     &api.CancelDownloadResult{},
+		},
+		{
+			name: "finished",
+			target: url.URL{
+				Path:     apiendpoints.DownloadCancel,
+				RawQuery: "id=" + url.QueryEscape(finished.ID()),
+			},
+			wantCode:   http.StatusConflict,
+			wantBodyRe: regexp.MustCompile(`(?i)^Download \S+ has already finished\b`),
+		},
+		{
+			name: "not found",
+			target: url.URL{
+				Path:     apiendpoints.DownloadCancel,
+				RawQuery: "id=0eb97f68",
+			},
+			wantCode:   http.StatusNotFound,
+			wantBodyRe: regexp.MustCompile(`(?i)^Download 0eb97f68 not found\b`),
+		},
+		{
+			name: "missing ID",
+			target: url.URL{
+				Path: apiendpoints.DownloadCancel,
+			},
+			wantCode:   http.StatusNotFound,
+			wantBodyRe: regexp.MustCompile(`(?i)^Download ID\b.*\brequired\b`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handlerTest{
+				handler:        newRouter(m, nil),
+				method:         http.MethodPost,
+				target:         tc.target,
+				wantStatusCode: tc.wantCode,
+				wantBodyMatch:  tc.wantBodyRe,
+				wantBodyJson:   tc.want,
+			}.do(t)
+		})
+	}
+
+	if err := pending.WriteArchive(context.Background(), io.Discard); err == nil {
+		t.Errorf("Cancelled download succeeded")
+	}
+
+	if sf := pending.Status().Finished; sf == nil || !sf.Cancelled {
+		t.Errorf("Download not recorded as cancelled: %+v", sf)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+func (m *manager) listDownloads() []api.DownloadStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]api.DownloadStatus, 0, len(m.downloads))
+
+	for _, d := range m.downloads {
+		result = append(result, d.Status())
+	}
+
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].ID < result[b].ID
+	})
+
+	return result
+}
+
+func (m *manager) handleListDownloads(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		return
+	}
+
+	writeJsonResponse(w, http.StatusOK, nil, api.ListDownloadsResult{
+		Downloads: m.listDownloads(),
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+	"github.com/hansmi/prombackup/internal/snapshotstream"
+)
+
+func TestListDownloads(t *testing.T) {
+	m, err := newManager(managerOptions{
+		snapshotDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("newManager() failed: %v", err)
+	}
+
+	var want []api.DownloadStatus
+
+	for _, name := range []string{"first-1", "second-2"} {
+		s, err := snapshotstream.New(snapshotstream.Options{
+			Name:          name,
+			Root:          &fstest.MapFS{},
+			Format:        api.ArchiveTarZstd,
+			ClientAddress: "192.0.2.1:1234",
+			UserAgent:     "test/1.0",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m.addDownload(s)
+
+		want = append(want, api.DownloadStatus{
+			ID:            s.ID(),
+			SnapshotName:  name,
+			Format:        api.ArchiveTarZstd,
+			ClientAddress: "192.0.2.1:1234",
+			UserAgent:     "test/1.0",
+		})
+	}
+
+	// The counter in IDs isn't zero-padded, hence the order of creation may
+	// differ from the order of IDs.
+	slices.SortFunc(want, func(a, b api.DownloadStatus) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	for _, tc := range []struct {
+		name       string
+		method     string
+		manager    *manager
+		wantCode   int
+		wantBodyRe *regexp.Regexp
+		want       *api.ListDownloadsResult
+	}{
+		{
+			name:     "success",
+			manager:  m,
+			wantCode: http.StatusOK,
+			want: &api.ListDownloadsResult{
+				Downloads: want,
+			},
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodPost,
+			manager:    m,
+			wantCode:   http.StatusMethodNotAllowed,
+			wantBodyRe: regexp.MustCompile(`(?i)^Method\b`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handlerTest{
+				handler: newRouter(tc.manager, nil),
+				method:  tc.method,
+				target: url.URL{
+					Path: apiendpoints.Downloads,
+				},
+				wantStatusCode: tc.wantCode,
+				wantBodyMatch:  tc.wantBodyRe,
+				wantBodyJson:   tc.want,
+				wantBodyJsonOpt: []cmp.Option{
+					cmpopts.IgnoreFields(api.DownloadStatus{}, "StartedAt"),
+				},
+			}.do(t)
+		})
+	}
+}
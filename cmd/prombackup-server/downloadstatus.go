@@ -1,8 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/hansmi/prombackup/internal/statusstore"
 )
 
 func (m *manager) handleDownloadStatus(w http.ResponseWriter, r *http.Request) {
@@ -22,10 +25,22 @@ func (m *manager) handleDownloadStatus(w http.ResponseWriter, r *http.Request) {
 	s := m.downloads[id]
 	m.mu.Unlock()
 
-	if s == nil {
-		http.Error(w, fmt.Sprintf("Download %s not found", id), http.StatusNotFound)
+	if s != nil {
+		writeJsonResponse(w, http.StatusOK, nil, s.Status())
+		return
+	}
+
+	// Downloads no longer tracked may have been recorded in the store
+	status, err := m.statusStore.Get(id)
+	if err != nil {
+		if errors.Is(err, statusstore.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("Download %s not found", id), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
 		return
 	}
 
-	writeJsonResponse(w, http.StatusOK, nil, s.Status())
+	writeJsonResponse(w, http.StatusOK, nil, status)
 }
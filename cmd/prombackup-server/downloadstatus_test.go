@@ -31,6 +31,19 @@ func TestDownloadStatus(t *testing.T) {
 
 	m.downloads[testStream.ID()] = testStream
 
+	storedStatus := api.DownloadStatus{
+		ID:           "1667766556_stored",
+		SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+		Finished: &api.DownloadStatusFinished{
+			Success:   true,
+			Sha256Hex: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+
+	if err := m.statusStore.Put(storedStatus); err != nil {
+		t.Error(err)
+	}
+
 	for _, tc := range []struct {
 		name       string
 		method     string
@@ -49,8 +62,19 @@ func TestDownloadStatus(t *testing.T) {
 			want: &api.DownloadStatus{
 				ID:           testStream.ID(),
 				SnapshotName: "test",
+				Format:       api.ArchiveTar,
+				StartedAt:    testStream.Status().StartedAt,
 			},
 		},
+		{
+			name: "stored",
+			target: url.URL{
+				Path:     apiendpoints.DownloadStatus,
+				RawQuery: "id=" + url.QueryEscape(storedStatus.ID),
+			},
+			wantCode: http.StatusOK,
+			want:     &storedStatus,
+		},
 		{
 			name:   "wrong method",
 			method: http.MethodPost,
@@ -23,6 +23,7 @@ type handlerTest struct {
 	wantHeaderMatch map[string]*regexp.Regexp
 	wantBodyMatch   *regexp.Regexp
 	wantBodyJson    any
+	wantBodyJsonOpt []cmp.Option
 }
 
 func (ht handlerTest) do(t *testing.T) (*http.Response, []byte) {
@@ -74,7 +75,7 @@ func (ht handlerTest) do(t *testing.T) (*http.Response, []byte) {
 				t.Errorf("Unmarshalling body %q failed: %v", body, err)
 			}
 
-			if diff := cmp.Diff(want.Interface(), got.Elem().Interface()); diff != "" {
+			if diff := cmp.Diff(want.Interface(), got.Elem().Interface(), ht.wantBodyJsonOpt...); diff != "" {
 				t.Errorf("Response body diff (-want +got):\n%s", diff)
 			}
 		}
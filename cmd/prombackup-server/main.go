@@ -2,16 +2,25 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
 	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
 	"time"
 
+	"filippo.io/age"
 	"github.com/gorilla/handlers"
 	"github.com/hansmi/prombackup/internal/clientcli"
+	"github.com/hansmi/prombackup/internal/snapshotstream"
+	"github.com/hansmi/prombackup/internal/statusstore"
 	"github.com/prometheus/client_golang/api"
 	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/client_golang/prometheus"
@@ -32,6 +41,68 @@ func listenAndServe(addr string, handler http.Handler) error {
 	return http.Serve(listener, handler)
 }
 
+// openStatusStore returns a store persisting records in the given directory.
+// An in-memory store is returned if the directory is empty.
+func openStatusStore(dir string, opts statusstore.Options) (statusstore.Store, error) {
+	if dir == "" {
+		return statusstore.NewMemory(opts), nil
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return statusstore.OpenFile(filepath.Join(dir, "download_status.jsonl"), opts)
+}
+
+// readRecipientsFile parses age recipients, one per line. No recipients are
+// returned if the path is empty.
+func readRecipientsFile(path string) ([]age.Recipient, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fh.Close()
+
+	return age.ParseRecipients(fh)
+}
+
+// readSigningKey reads an Ed25519 private key in PEM-encoded PKCS #8 format,
+// e.g. as generated by "openssl genpkey -algorithm ed25519". No key is
+// returned if the path is empty.
+func readSigningKey(path string) (ed25519.PrivateKey, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: no PEM-encoded private key found", path)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	result, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported key type %T, want Ed25519", path, key)
+	}
+
+	return result, nil
+}
+
 func main() {
 	showVersion := flag.Bool("version", false, "Output version information and exit.")
 
@@ -41,6 +112,24 @@ func main() {
 		"HTTP address for Prometheus API. Defaults to the PROMBACKUP_SERVER_PROMETHEUS_ENDPOINT environment variable.")
 	snapshotDir := flag.String("snapshot_dir", clientcli.GetenvWithFallback("PROMBACKUP_SERVER_SNAPSHOT_DIR", ""),
 		"Base directory for snapshots. Defaults to the PROMBACKUP_SERVER_SNAPSHOT_DIR environment variable.")
+	stateDir := flag.String("state_dir", clientcli.GetenvWithFallback("PROMBACKUP_SERVER_STATE_DIR", ""),
+		"Directory for persisting the status of finished downloads across restarts. The status is only kept in memory if empty. Defaults to the PROMBACKUP_SERVER_STATE_DIR environment variable.")
+	encryptionRecipientsFile := flag.String("encryption_recipients_file", clientcli.GetenvWithFallback("PROMBACKUP_SERVER_ENCRYPTION_RECIPIENTS_FILE", ""),
+		"File with age recipients (public keys), one per line. If set all archives are encrypted to these recipients in addition to any requested by the client. Defaults to the PROMBACKUP_SERVER_ENCRYPTION_RECIPIENTS_FILE environment variable.")
+	signingKeyFile := flag.String("signing_key_file", clientcli.GetenvWithFallback("PROMBACKUP_SERVER_SIGNING_KEY_FILE", ""),
+		"File with an Ed25519 private key in PEM-encoded PKCS #8 format for signing the checksum of downloaded archives. Defaults to the PROMBACKUP_SERVER_SIGNING_KEY_FILE environment variable.")
+	downloadStatusRetention := flag.Duration("download_status_retention", clientcli.MustGetenvDuration("PROMBACKUP_SERVER_DOWNLOAD_STATUS_RETENTION", 24*time.Hour),
+		"How long to keep the status of finished downloads. Zero keeps it indefinitely. Defaults to the PROMBACKUP_SERVER_DOWNLOAD_STATUS_RETENTION environment variable.")
+	downloadLifetime := flag.Duration("download_lifetime", clientcli.MustGetenvDuration("PROMBACKUP_SERVER_DOWNLOAD_LIFETIME", 15*time.Minute),
+		"How long to track downloads after they finished. Snapshots can't be removed while a download is tracked, unless it was cancelled. Defaults to the PROMBACKUP_SERVER_DOWNLOAD_LIFETIME environment variable.")
+	downloadMaxTracked := flag.Int("download_max_tracked", clientcli.MustGetenvInt("PROMBACKUP_SERVER_DOWNLOAD_MAX_TRACKED", 100),
+		"Maximum number of tracked downloads. The oldest finished download is no longer tracked when the limit is reached. Zero disables the limit. Defaults to the PROMBACKUP_SERVER_DOWNLOAD_MAX_TRACKED environment variable.")
+	downloadMaxCompressionLevel := flag.Int("download_max_compression_level", clientcli.MustGetenvInt("PROMBACKUP_SERVER_DOWNLOAD_MAX_COMPRESSION_LEVEL", 0),
+		"Maximum compression level clients may request. Zero permits all levels supported by the archive format. Defaults to the PROMBACKUP_SERVER_DOWNLOAD_MAX_COMPRESSION_LEVEL environment variable.")
+	downloadMaxCompressionWindow := flag.Int("download_max_compression_window", clientcli.MustGetenvInt("PROMBACKUP_SERVER_DOWNLOAD_MAX_COMPRESSION_WINDOW", 8*1024*1024),
+		"Maximum Zstandard window size in bytes clients may request. Zero disables the limit. Defaults to the PROMBACKUP_SERVER_DOWNLOAD_MAX_COMPRESSION_WINDOW environment variable.")
+	downloadMaxCompressionConcurrency := flag.Int("download_max_compression_concurrency", clientcli.MustGetenvInt("PROMBACKUP_SERVER_DOWNLOAD_MAX_COMPRESSION_CONCURRENCY", runtime.NumCPU()),
+		"Maximum number of compression goroutines per download for formats supporting parallel compression (tgz and tzst). Also used when a client doesn't request a specific number. Zero disables the limit. Defaults to the PROMBACKUP_SERVER_DOWNLOAD_MAX_COMPRESSION_CONCURRENCY environment variable.")
 
 	autopruneEnabled := flag.Bool("autoprune", clientcli.MustGetenvBool("PROMBACKUP_SERVER_AUTOPRUNE_ENABLE", false),
 		"Remove snapshots in regular intervals. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_ENABLE environment variable.")
@@ -48,6 +137,22 @@ func main() {
 		"How often to automatically remove snapshots. The interval is randomized by a small amount. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_INTERVAL environment variable.")
 	autopruneKeepWithin := flag.Duration("autoprune_keep_within", clientcli.MustGetenvDuration("PROMBACKUP_SERVER_AUTOPRUNE_KEEP_WITHIN", time.Hour),
 		"Keep snapshots younger than this amount of time when automatically removing them. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_KEEP_WITHIN environment variable.")
+	autopruneKeepLast := flag.Int("autoprune_keep_last", clientcli.MustGetenvInt("PROMBACKUP_SERVER_AUTOPRUNE_KEEP_LAST", 0),
+		"Keep this number of most recent snapshots when automatically removing them. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_KEEP_LAST environment variable.")
+	autopruneKeepHourly := flag.Int("autoprune_keep_hourly", clientcli.MustGetenvInt("PROMBACKUP_SERVER_AUTOPRUNE_KEEP_HOURLY", 0),
+		"Keep the most recent snapshot for this number of hours. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_KEEP_HOURLY environment variable.")
+	autopruneKeepDaily := flag.Int("autoprune_keep_daily", clientcli.MustGetenvInt("PROMBACKUP_SERVER_AUTOPRUNE_KEEP_DAILY", 0),
+		"Keep the most recent snapshot for this number of days. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_KEEP_DAILY environment variable.")
+	autopruneKeepWeekly := flag.Int("autoprune_keep_weekly", clientcli.MustGetenvInt("PROMBACKUP_SERVER_AUTOPRUNE_KEEP_WEEKLY", 0),
+		"Keep the most recent snapshot for this number of weeks. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_KEEP_WEEKLY environment variable.")
+	autopruneKeepMonthly := flag.Int("autoprune_keep_monthly", clientcli.MustGetenvInt("PROMBACKUP_SERVER_AUTOPRUNE_KEEP_MONTHLY", 0),
+		"Keep the most recent snapshot for this number of months. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_KEEP_MONTHLY environment variable.")
+	autopruneMinFreeBytes := flag.Uint64("autoprune_min_free_bytes", clientcli.MustGetenvUint64("PROMBACKUP_SERVER_AUTOPRUNE_MIN_FREE_BYTES", 0),
+		"Remove the oldest remaining snapshots until the snapshot filesystem has at least this many bytes available. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_MIN_FREE_BYTES environment variable.")
+	autopruneMinFreePercent := flag.Float64("autoprune_min_free_percent", clientcli.MustGetenvFloat64("PROMBACKUP_SERVER_AUTOPRUNE_MIN_FREE_PERCENT", 0),
+		"Remove the oldest remaining snapshots until the snapshot filesystem has at least this percentage of its capacity available. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_MIN_FREE_PERCENT environment variable.")
+	autopruneMinKeep := flag.Int("autoprune_min_keep", clientcli.MustGetenvInt("PROMBACKUP_SERVER_AUTOPRUNE_MIN_KEEP", 1),
+		"Minimum number of snapshots to keep when removing snapshots to free space. Defaults to the PROMBACKUP_SERVER_AUTOPRUNE_MIN_KEEP environment variable.")
 
 	flag.Parse()
 
@@ -81,11 +186,41 @@ func main() {
 		log.Fatalf("Creating Prometheus client failed: %v", err)
 	}
 
+	statusStore, err := openStatusStore(*stateDir, statusstore.Options{
+		Retention: *downloadStatusRetention,
+		Logger:    log.Default(),
+	})
+	if err != nil {
+		log.Fatalf("Opening download status store failed: %v", err)
+	}
+
+	recipients, err := readRecipientsFile(*encryptionRecipientsFile)
+	if err != nil {
+		log.Fatalf("Reading encryption recipients failed: %v", err)
+	}
+
+	signingKey, err := readSigningKey(*signingKeyFile)
+	if err != nil {
+		log.Fatalf("Reading signing key failed: %v", err)
+	}
+
 	m, err := newManager(managerOptions{
 		logger:      log.Default(),
 		registry:    prometheus.WrapRegistererWithPrefix("prombackup_server_", registry),
 		admin:       promv1.NewAPI(client),
 		snapshotDir: *snapshotDir,
+		statusStore: statusStore,
+		recipients:  recipients,
+		signingKey:  signingKey,
+
+		downloadLifetime:    *downloadLifetime,
+		maxTrackedDownloads: *downloadMaxTracked,
+
+		compressionLimits: snapshotstream.CompressionOptions{
+			Level:       *downloadMaxCompressionLevel,
+			Window:      *downloadMaxCompressionWindow,
+			Concurrency: *downloadMaxCompressionConcurrency,
+		},
 	})
 	if err != nil {
 		log.Fatalf("Creating manager failed: %v", err)
@@ -97,6 +232,14 @@ func main() {
 			opts:     m.defaultPruneOptions(),
 		}
 		p.opts.KeepWithin = *autopruneKeepWithin
+		p.opts.KeepLast = *autopruneKeepLast
+		p.opts.KeepHourly = *autopruneKeepHourly
+		p.opts.KeepDaily = *autopruneKeepDaily
+		p.opts.KeepWeekly = *autopruneKeepWeekly
+		p.opts.KeepMonthly = *autopruneKeepMonthly
+		p.opts.MinFreeBytes = *autopruneMinFreeBytes
+		p.opts.MinFreePercent = *autopruneMinFreePercent
+		p.opts.MinKeep = *autopruneMinKeep
 
 		go p.run(context.Background())
 	}
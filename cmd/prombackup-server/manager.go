@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"embed"
 	"encoding/json"
 	"errors"
@@ -17,7 +18,9 @@ import (
 	"sync"
 	"time"
 
+	"filippo.io/age"
 	"github.com/hansmi/prombackup/internal/snapshotstream"
+	"github.com/hansmi/prombackup/internal/statusstore"
 	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -70,6 +73,30 @@ type managerOptions struct {
 	registry    prometheus.Registerer
 	admin       adminAPI
 	snapshotDir string
+
+	// Store for the status of finished downloads. Defaults to an in-memory
+	// store.
+	statusStore statusstore.Store
+
+	// Archives are always encrypted to these recipients.
+	recipients []age.Recipient
+
+	// Key for signing the digest of downloaded archives. Archives are not
+	// signed if nil.
+	signingKey ed25519.PrivateKey
+
+	// How long downloads are tracked after they finished. Defaults to 15
+	// minutes.
+	downloadLifetime time.Duration
+
+	// Maximum number of tracked downloads. The oldest finished download is
+	// evicted when the limit is reached. Downloads still in progress are
+	// never evicted. Zero disables the limit.
+	maxTrackedDownloads int
+
+	// Upper bounds for the compression settings requested by clients. Zero
+	// disables the respective limit.
+	compressionLimits snapshotstream.CompressionOptions
 }
 
 type manager struct {
@@ -79,9 +106,20 @@ type manager struct {
 	snapshotRootPath string
 	downloadLifetime time.Duration
 	template         *template.Template
+	statusStore      statusstore.Store
+
+	maxTrackedDownloads int
+	evictedDownloads    prometheus.Counter
+
+	compressionLimits snapshotstream.CompressionOptions
+	recipients        []age.Recipient
+	signingKey        ed25519.PrivateKey
 
 	mu        sync.Mutex
 	downloads map[string]*snapshotstream.Stream
+
+	// Time at which each tracked download finished.
+	finishedAt map[string]time.Time
 }
 
 func newManager(opts managerOptions) (*manager, error) {
@@ -92,15 +130,33 @@ func newManager(opts managerOptions) (*manager, error) {
 		snapshotRoot:     os.DirFS(opts.snapshotDir),
 		snapshotRootPath: opts.snapshotDir,
 		logger:           opts.logger,
-		downloadLifetime: 15 * time.Minute,
+		downloadLifetime: opts.downloadLifetime,
+		statusStore:      opts.statusStore,
+
+		maxTrackedDownloads: opts.maxTrackedDownloads,
 
-		downloads: map[string]*snapshotstream.Stream{},
+		compressionLimits: opts.compressionLimits,
+		recipients:        opts.recipients,
+		signingKey:        opts.signingKey,
+
+		downloads:  map[string]*snapshotstream.Stream{},
+		finishedAt: map[string]time.Time{},
+	}
+
+	if m.downloadLifetime <= 0 {
+		m.downloadLifetime = 15 * time.Minute
 	}
 
 	if m.logger == nil {
 		m.logger = log.New(io.Discard, "", 0)
 	}
 
+	if m.statusStore == nil {
+		m.statusStore = statusstore.NewMemory(statusstore.Options{
+			Retention: 24 * time.Hour,
+		})
+	}
+
 	if m.template, err = template.ParseFS(contentTemplate, "template/*.tmpl"); err != nil {
 		return nil, err
 	}
@@ -111,15 +167,26 @@ func newManager(opts managerOptions) (*manager, error) {
 		Name:      "tracked_count",
 	}, m.downloadsCountMetric)
 
+	m.evictedDownloads = f.NewCounter(prometheus.CounterOpts{
+		Subsystem: "download",
+		Name:      "evicted_total",
+	})
+
 	return m, nil
 }
 
+// checkSnapshotBeforeRemove returns errSnapshotInUse while a download of the
+// named snapshot is tracked. Cancelled downloads don't block removal.
 func (m *manager) checkSnapshotBeforeRemove(name string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	for _, d := range m.downloads {
-		if d.Name() == name {
+		if d.Name() != name {
+			continue
+		}
+
+		if sf := d.Status().Finished; sf == nil || !sf.Cancelled {
 			return fmt.Errorf("%w: download %s", errSnapshotInUse, d.ID())
 		}
 	}
@@ -141,9 +208,55 @@ func (m *manager) deleteDownloadAfter(id string, delay time.Duration) {
 }
 
 func (m *manager) deleteDownload(id string) {
-	m.logger.Printf("Download %s has expired", id)
-
 	m.mu.Lock()
+	_, ok := m.downloads[id]
 	delete(m.downloads, id)
+	delete(m.finishedAt, id)
 	m.mu.Unlock()
+
+	if ok {
+		m.logger.Printf("Download %s has expired", id)
+	}
+}
+
+// addDownload starts tracking a download, evicting the oldest finished
+// downloads if necessary.
+func (m *manager) addDownload(s *snapshotstream.Stream) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.maxTrackedDownloads > 0 && len(m.downloads) >= m.maxTrackedDownloads {
+		var oldestID string
+		var oldest time.Time
+
+		for id, ts := range m.finishedAt {
+			if oldestID == "" || ts.Before(oldest) {
+				oldestID = id
+				oldest = ts
+			}
+		}
+
+		if oldestID == "" {
+			// All tracked downloads are in progress
+			break
+		}
+
+		m.logger.Printf("Evicting download %s", oldestID)
+
+		delete(m.downloads, oldestID)
+		delete(m.finishedAt, oldestID)
+
+		m.evictedDownloads.Inc()
+	}
+
+	m.downloads[s.ID()] = s
+}
+
+func (m *manager) markDownloadFinished(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.downloads[id]; ok {
+		m.finishedAt[id] = time.Now()
+	}
 }
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/hansmi/prombackup/api"
 	"github.com/hansmi/prombackup/internal/snapshotstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestDeleteDownload(t *testing.T) {
@@ -33,3 +43,128 @@ func TestDeleteDownload(t *testing.T) {
 		t.Errorf("Download wasn't deleted: %v", m.downloads)
 	}
 }
+
+func TestAddDownloadEviction(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+
+	m, err := newManager(managerOptions{
+		registry:            registry,
+		snapshotDir:         t.TempDir(),
+		maxTrackedDownloads: 2,
+	})
+	if err != nil {
+		t.Fatalf("newManager() failed: %v", err)
+	}
+
+	tracked := func() []string {
+		var result []string
+
+		for _, s := range m.downloads {
+			result = append(result, s.Name())
+		}
+
+		sort.Strings(result)
+
+		return result
+	}
+
+	for _, tc := range []struct {
+		name     string
+		finished []string
+		want     []string
+	}{
+		{name: "a-1", want: []string{"a-1"}},
+		{name: "b-2", want: []string{"a-1", "b-2"}},
+		{name: "c-3", finished: []string{"b-2", "a-1"}, want: []string{"a-1", "c-3"}},
+		{name: "d-4", want: []string{"c-3", "d-4"}},
+
+		// All downloads are in progress and can't be evicted
+		{name: "e-5", want: []string{"c-3", "d-4", "e-5"}},
+	} {
+		s, err := snapshotstream.New(snapshotstream.Options{
+			Name:   tc.name,
+			Root:   &fstest.MapFS{},
+			Format: api.ArchiveTar,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for idx, finished := range tc.finished {
+			for id, s := range m.downloads {
+				if s.Name() == finished {
+					m.markDownloadFinished(id)
+					m.finishedAt[id] = time.Date(2022, 11, 9, 0, idx, 0, 0, time.UTC)
+				}
+			}
+		}
+
+		m.addDownload(s)
+
+		if diff := cmp.Diff(tc.want, tracked()); diff != "" {
+			t.Errorf("Tracked downloads after adding %s diff (-want +got):\n%s", tc.name, diff)
+		}
+	}
+
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP download_evicted_total
+# TYPE download_evicted_total counter
+download_evicted_total 2
+`), "download_evicted_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckSnapshotBeforeRemove(t *testing.T) {
+	m, err := newManager(managerOptions{
+		snapshotDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("newManager() failed: %v", err)
+	}
+
+	for _, name := range []string{"running-1", "finished-2", "cancelled-3"} {
+		s, err := snapshotstream.New(snapshotstream.Options{
+			Name:   name,
+			Root:   &fstest.MapFS{},
+			Format: api.ArchiveTar,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		switch name {
+		case "finished-2":
+			if err := s.WriteArchive(context.Background(), io.Discard); err != nil {
+				t.Fatal(err)
+			}
+
+		case "cancelled-3":
+			s.Cancel()
+
+			if err := s.WriteArchive(context.Background(), io.Discard); !errors.Is(err, snapshotstream.ErrCancelled) {
+				t.Fatalf("WriteArchive() returned %v, want %v", err, snapshotstream.ErrCancelled)
+			}
+		}
+
+		m.addDownload(s)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		wantErr error
+	}{
+		{name: "running-1", wantErr: errSnapshotInUse},
+		{name: "finished-2", wantErr: errSnapshotInUse},
+		{name: "cancelled-3"},
+		{name: "other-4"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := m.checkSnapshotBeforeRemove(tc.name)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -3,9 +3,9 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/hansmi/prombackup/api"
 	"github.com/hansmi/prombackup/internal/pruner"
 )
 
@@ -38,10 +38,65 @@ func (m *manager) handlePrune(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := pruner.Prune(r.Context(), opts); err != nil {
+	for _, i := range []struct {
+		name  string
+		value *int
+	}{
+		{"keep_last", &opts.KeepLast},
+		{"keep_hourly", &opts.KeepHourly},
+		{"keep_daily", &opts.KeepDaily},
+		{"keep_weekly", &opts.KeepWeekly},
+		{"keep_monthly", &opts.KeepMonthly},
+		{"min_keep", &opts.MinKeep},
+	} {
+		if raw := r.Form.Get(i.name); raw != "" {
+			if value, err := strconv.Atoi(raw); err != nil {
+				http.Error(w, fmt.Sprintf("Parsing %s: %v", i.name, err.Error()), http.StatusBadRequest)
+				return
+			} else if value < 0 {
+				http.Error(w, fmt.Sprintf("Parsing %s: value must not be negative", i.name), http.StatusBadRequest)
+				return
+			} else {
+				*i.value = value
+			}
+		}
+	}
+
+	if raw := r.Form.Get("min_free_bytes"); raw != "" {
+		if value, err := strconv.ParseUint(raw, 10, 64); err != nil {
+			http.Error(w, fmt.Sprintf("Parsing min_free_bytes: %v", err.Error()), http.StatusBadRequest)
+			return
+		} else {
+			opts.MinFreeBytes = value
+		}
+	}
+
+	if raw := r.Form.Get("min_free_percent"); raw != "" {
+		if value, err := strconv.ParseFloat(raw, 64); err != nil {
+			http.Error(w, fmt.Sprintf("Parsing min_free_percent: %v", err.Error()), http.StatusBadRequest)
+			return
+		} else if !(value >= 0 && value <= 100) {
+			http.Error(w, "Parsing min_free_percent: value must be between 0 and 100", http.StatusBadRequest)
+			return
+		} else {
+			opts.MinFreePercent = value
+		}
+	}
+
+	if raw := r.Form.Get("dry_run"); raw != "" {
+		if value, err := strconv.ParseBool(raw); err != nil {
+			http.Error(w, fmt.Sprintf("Parsing dry_run: %v", err.Error()), http.StatusBadRequest)
+			return
+		} else {
+			opts.DryRun = value
+		}
+	}
+
+	result, err := pruner.Prune(r.Context(), opts)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	writeJsonResponse(w, http.StatusOK, nil, api.PruneResult{})
+	writeJsonResponse(w, http.StatusOK, nil, result)
 }
@@ -6,6 +6,8 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/hansmi/prombackup/api"
 	"github.com/hansmi/prombackup/internal/apiendpoints"
 )
@@ -46,6 +48,90 @@ func TestPrune(t *testing.T) {
 			wantCode:   http.StatusBadRequest,
 			wantBodyRe: regexp.MustCompile(`(?i): invalid duration\b`),
 		},
+		{
+			name:   "retention rules",
+			method: http.MethodPost,
+			target: url.URL{
+				Path:     apiendpoints.Prune,
+				RawQuery: "keep_last=3&keep_hourly=1&keep_daily=7&keep_weekly=4&keep_monthly=6",
+			},
+			wantCode: http.StatusOK,
+			want:     &api.PruneResult{},
+		},
+		{
+			name:   "dry run",
+			method: http.MethodPost,
+			target: url.URL{
+				Path:     apiendpoints.Prune,
+				RawQuery: "dry_run=true",
+			},
+			wantCode: http.StatusOK,
+			want: &api.PruneResult{
+				DryRun: true,
+			},
+		},
+		{
+			name:   "bad dry_run",
+			method: http.MethodPost,
+			target: url.URL{
+				Path:     apiendpoints.Prune,
+				RawQuery: "dry_run=maybe",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing dry_run: .*invalid syntax\b`),
+		},
+		{
+			name:   "free space",
+			method: http.MethodPost,
+			target: url.URL{
+				Path:     apiendpoints.Prune,
+				RawQuery: "min_free_bytes=1&min_free_percent=0.5&min_keep=1&dry_run=1",
+			},
+			wantCode: http.StatusOK,
+			want: &api.PruneResult{
+				DryRun: true,
+			},
+		},
+		{
+			name:   "bad min_free_percent",
+			method: http.MethodPost,
+			target: url.URL{
+				Path:     apiendpoints.Prune,
+				RawQuery: "min_free_percent=101",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing min_free_percent: .*\bbetween\b`),
+		},
+		{
+			name:   "bad min_free_bytes",
+			method: http.MethodPost,
+			target: url.URL{
+				Path:     apiendpoints.Prune,
+				RawQuery: "min_free_bytes=-1",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing min_free_bytes: .*invalid syntax\b`),
+		},
+		{
+			name:   "bad keep_daily",
+			method: http.MethodPost,
+			target: url.URL{
+				Path:     apiendpoints.Prune,
+				RawQuery: "keep_daily=many",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing keep_daily: .*invalid syntax\b`),
+		},
+		{
+			name:   "negative keep_last",
+			method: http.MethodPost,
+			target: url.URL{
+				Path:     apiendpoints.Prune,
+				RawQuery: "keep_last=-1",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Parsing keep_last: .*\bnegative\b`),
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			m, err := newManager(managerOptions{
@@ -62,6 +148,9 @@ func TestPrune(t *testing.T) {
 				wantStatusCode: tc.wantCode,
 				wantBodyMatch:  tc.wantBodyRe,
 				wantBodyJson:   tc.want,
+				wantBodyJsonOpt: []cmp.Option{
+					cmpopts.IgnoreFields(api.PruneResult{}, "Duration"),
+				},
 			}.do(t)
 		})
 	}
@@ -1,16 +1,46 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
-	"sort"
+	"slices"
+	"time"
 
 	"github.com/hansmi/prombackup/api"
 )
 
+// describeDownload returns human-readable descriptions of the state and
+// progress of a download.
+func describeDownload(status api.DownloadStatus, now time.Time) (state, progress string) {
+	state = status.State()
+
+	if p := status.Progress; p != nil {
+		progress = fmt.Sprintf("%d/%d files, %d/%d bytes read, %d bytes written",
+			p.FilesDone, p.FilesTotal, p.BytesRead, p.BytesTotal, p.BytesWritten)
+
+		if status.Finished == nil {
+			if fraction, ok := p.Fraction(); ok {
+				progress += fmt.Sprintf(" (%.1f%%", 100*fraction)
+
+				if remaining, ok := p.Remaining(now); ok {
+					progress += fmt.Sprintf(", %v remaining", remaining.Round(time.Second))
+				}
+
+				progress += ")"
+			}
+		}
+	}
+
+	return state, progress
+}
+
 func (m *manager) handleRoot(w http.ResponseWriter, r *http.Request) {
 	type downloadInfo struct {
-		ID   string
-		Name string
+		ID          string
+		Name        string
+		State       string
+		Progress    string
+		Cancellable bool
 	}
 
 	var data struct {
@@ -20,18 +50,24 @@ func (m *manager) handleRoot(w http.ResponseWriter, r *http.Request) {
 
 	data.ArchiveFormats = api.ArchiveFormatAll
 
-	m.mu.Lock()
-	for _, d := range m.downloads {
-		data.Downloads = append(data.Downloads, downloadInfo{
-			ID:   d.ID(),
-			Name: d.Name(),
-		})
-	}
-	m.mu.Unlock()
+	now := time.Now()
+
+	downloads := m.listDownloads()
+
+	// Most recent first
+	slices.Reverse(downloads)
 
-	sort.Slice(data.Downloads, func(a, b int) bool {
-		return data.Downloads[a].ID > data.Downloads[b].ID
-	})
+	for _, status := range downloads {
+		info := downloadInfo{
+			ID:          status.ID,
+			Name:        status.SnapshotName,
+			Cancellable: status.Finished == nil,
+		}
+
+		info.State, info.Progress = describeDownload(status, now)
+
+		data.Downloads = append(data.Downloads, info)
+	}
 
 	if err := m.template.Execute(w, data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
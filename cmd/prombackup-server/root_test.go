@@ -5,6 +5,9 @@ import (
 	"net/url"
 	"regexp"
 	"testing"
+	"time"
+
+	"github.com/hansmi/prombackup/api"
 )
 
 func TestRoot(t *testing.T) {
@@ -52,3 +55,89 @@ func TestRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestDescribeDownload(t *testing.T) {
+	startedAt := time.Date(2022, 11, 9, 20, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name         string
+		status       api.DownloadStatus
+		wantState    string
+		wantProgress string
+	}{
+		{
+			name:      "pending",
+			wantState: "pending",
+		},
+		{
+			name: "in progress",
+			status: api.DownloadStatus{
+				Progress: &api.DownloadProgress{
+					StartedAt:    startedAt,
+					BytesRead:    250,
+					BytesTotal:   1000,
+					BytesWritten: 100,
+					FilesDone:    1,
+					FilesTotal:   4,
+				},
+			},
+			wantState:    "in progress",
+			wantProgress: "1/4 files, 250/1000 bytes read, 100 bytes written (25.0%, 3m0s remaining)",
+		},
+		{
+			name: "finished",
+			status: api.DownloadStatus{
+				Progress: &api.DownloadProgress{
+					StartedAt:    startedAt,
+					BytesRead:    1000,
+					BytesTotal:   1000,
+					BytesWritten: 400,
+					FilesDone:    4,
+					FilesTotal:   4,
+				},
+				Finished: &api.DownloadStatusFinished{
+					Success: true,
+				},
+			},
+			wantState:    "finished",
+			wantProgress: "4/4 files, 1000/1000 bytes read, 400 bytes written",
+		},
+		{
+			name: "cancelled",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{
+					Cancelled: true,
+				},
+			},
+			wantState: "cancelled",
+		},
+		{
+			name: "aborted",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{
+					ClientAborted: true,
+				},
+			},
+			wantState: "aborted",
+		},
+		{
+			name: "failed",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{},
+			},
+			wantState: "failed",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state, progress := describeDownload(tc.status, startedAt.Add(time.Minute))
+
+			if state != tc.wantState {
+				t.Errorf("State is %q, want %q", state, tc.wantState)
+			}
+
+			if progress != tc.wantProgress {
+				t.Errorf("Progress is %q, want %q", progress, tc.wantProgress)
+			}
+		})
+	}
+}
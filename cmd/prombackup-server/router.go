@@ -19,9 +19,16 @@ func newRouter(m *manager, registry *prometheus.Registry) http.Handler {
 
 	r.HandleFunc("/", m.handleRoot).Methods(http.MethodGet)
 	r.HandleFunc("/api/snapshot", m.handleSnapshot).Methods(http.MethodPost, http.MethodOptions)
+	r.HandleFunc("/api/snapshot", m.handleDeleteSnapshot).Methods(http.MethodDelete, http.MethodOptions)
+	r.HandleFunc("/api/snapshot/pin", m.handlePinSnapshot).Methods(http.MethodPost, http.MethodOptions)
+	r.HandleFunc("/api/snapshot/unpin", m.handleUnpinSnapshot).Methods(http.MethodPost, http.MethodOptions)
+	r.HandleFunc("/api/snapshots", m.handleListSnapshots).Methods(http.MethodGet, http.MethodOptions)
 	r.HandleFunc("/api/download", m.handleDownload).Methods(http.MethodGet, http.MethodOptions)
+	r.HandleFunc("/api/download/cancel", m.handleCancelDownload).Methods(http.MethodPost, http.MethodOptions)
 	r.HandleFunc("/api/download_status", m.handleDownloadStatus).Methods(http.MethodGet, http.MethodOptions)
+	r.HandleFunc("/api/downloads", m.handleListDownloads).Methods(http.MethodGet, http.MethodOptions)
 	r.HandleFunc("/api/prune", m.handlePrune).Methods(http.MethodPost, http.MethodOptions)
+	r.HandleFunc("/api/signing_key", m.handleSigningKey).Methods(http.MethodGet, http.MethodOptions)
 
 	if registry != nil {
 		r.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{
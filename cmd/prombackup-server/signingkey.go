@@ -0,0 +1,24 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+func (m *manager) handleSigningKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		return
+	}
+
+	if m.signingKey == nil {
+		http.Error(w, "No signing key configured", http.StatusNotFound)
+		return
+	}
+
+	writeJsonResponse(w, http.StatusOK, nil, api.SigningKeyResult{
+		PublicKeyHex: hex.EncodeToString(m.signingKey.Public().(ed25519.PublicKey)),
+	})
+}
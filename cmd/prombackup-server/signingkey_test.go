@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func TestSigningKey(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		method     string
+		signingKey ed25519.PrivateKey
+		wantCode   int
+		wantBodyRe *regexp.Regexp
+		want       *api.SigningKeyResult
+	}{
+		{
+			name:       "success",
+			signingKey: privateKey,
+			wantCode:   http.StatusOK,
+			want: &api.SigningKeyResult{
+				PublicKeyHex: hex.EncodeToString(publicKey),
+			},
+		},
+		{
+			name:       "no key",
+			wantCode:   http.StatusNotFound,
+			wantBodyRe: regexp.MustCompile(`(?i)^No signing key configured\b`),
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodPost,
+			signingKey: privateKey,
+			wantCode:   http.StatusMethodNotAllowed,
+			wantBodyRe: regexp.MustCompile(`(?i)^Method\b`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newManager(managerOptions{
+				snapshotDir: t.TempDir(),
+				signingKey:  tc.signingKey,
+			})
+			if err != nil {
+				t.Fatalf("newManager() failed: %v", err)
+			}
+
+			handlerTest{
+				handler: newRouter(m, nil),
+				method:  tc.method,
+				target: url.URL{
+					Path: apiendpoints.SigningKey,
+				},
+				wantStatusCode: tc.wantCode,
+				wantBodyMatch:  tc.wantBodyRe,
+				wantBodyJson:   tc.want,
+			}.do(t)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/pruner"
+)
+
+func (m *manager) handleDeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		return
+	}
+
+	name, ok := m.lookupSnapshot(w, r)
+	if !ok {
+		return
+	}
+
+	if pinned, err := pruner.IsPinned(m.snapshotRootPath, name); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if pinned {
+		http.Error(w, fmt.Sprintf("Not removing snapshot %s: snapshot is pinned", name), http.StatusLocked)
+		return
+	}
+
+	if err := m.checkSnapshotBeforeRemove(name); err != nil {
+		code := http.StatusInternalServerError
+
+		if errors.Is(err, errSnapshotInUse) {
+			code = http.StatusConflict
+		}
+
+		http.Error(w, fmt.Sprintf("Not removing snapshot %s: %v", name, err), code)
+		return
+	}
+
+	m.logger.Printf("Delete snapshot %s", name)
+
+	if err := pruner.Remove(m.snapshotRootPath, name); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJsonResponse(w, http.StatusOK, nil, api.DeleteSnapshotResult{})
+}
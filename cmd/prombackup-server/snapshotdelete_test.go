@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+	"github.com/hansmi/prombackup/internal/pruner"
+	"github.com/hansmi/prombackup/internal/snapshotstream"
+)
+
+func TestDeleteSnapshot(t *testing.T) {
+	const snapshotName = "20221109T202035Z-355a5b4970d5a906"
+
+	for _, tc := range []struct {
+		name        string
+		method      string
+		inUse       bool
+		pinned      bool
+		target      url.URL
+		wantCode    int
+		wantHeader  map[string]*regexp.Regexp
+		wantBodyRe  *regexp.Regexp
+		want        *api.DeleteSnapshotResult
+		wantRemoved bool
+	}{
+		{
+			name: "success",
+			target: url.URL{
+				Path:     apiendpoints.Snapshot,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode:    http.StatusOK,
+			want:        &api.DeleteSnapshotResult{},
+			wantRemoved: true,
+		},
+		{
+			name:   "preflight",
+			method: http.MethodOptions,
+			target: url.URL{
+				Path:     apiendpoints.Snapshot,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode: http.StatusOK,
+			wantHeader: map[string]*regexp.Regexp{
+				"Access-Control-Allow-Methods": regexp.MustCompile(`\bDELETE\b`),
+			},
+		},
+		{
+			name:  "in use",
+			inUse: true,
+			target: url.URL{
+				Path:     apiendpoints.Snapshot,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode:   http.StatusConflict,
+			wantBodyRe: regexp.MustCompile(`(?i)^Not removing snapshot\b.*\bsnapshot in use\b`),
+		},
+		{
+			name:   "pinned",
+			pinned: true,
+			target: url.URL{
+				Path:     apiendpoints.Snapshot,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode:   http.StatusLocked,
+			wantBodyRe: regexp.MustCompile(`(?i)^Not removing snapshot\b.*\bpinned\b`),
+		},
+		{
+			name: "not found",
+			target: url.URL{
+				Path:     apiendpoints.Snapshot,
+				RawQuery: "name=20221110T101010Z-0a1b2c3d4e5f6789",
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "missing name",
+			target: url.URL{
+				Path: apiendpoints.Snapshot,
+			},
+			wantCode:   http.StatusNotFound,
+			wantBodyRe: regexp.MustCompile(`(?i)^Snapshot name is required\b`),
+		},
+		{
+			name: "bad name",
+			target: url.URL{
+				Path:     apiendpoints.Snapshot,
+				RawQuery: "name=../escape",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Invalid snapshot name\b`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpdir := t.TempDir()
+			snapshotDir := filepath.Join(tmpdir, snapshotName)
+
+			if err := os.MkdirAll(filepath.Join(snapshotDir, "block"), 0o700); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := newManager(managerOptions{
+				snapshotDir: tmpdir,
+			})
+			if err != nil {
+				t.Fatalf("newManager() failed: %v", err)
+			}
+
+			if tc.pinned {
+				if err := pruner.Pin(tmpdir, snapshotName); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tc.inUse {
+				s, err := snapshotstream.New(snapshotstream.Options{
+					Name:   snapshotName,
+					Root:   &fstest.MapFS{},
+					Format: api.ArchiveTar,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				m.downloads[s.ID()] = s
+			}
+
+			method := tc.method
+
+			if method == "" {
+				method = http.MethodDelete
+			}
+
+			handlerTest{
+				handler:         newRouter(m, nil),
+				method:          method,
+				target:          tc.target,
+				wantStatusCode:  tc.wantCode,
+				wantHeaderMatch: tc.wantHeader,
+				wantBodyMatch:   tc.wantBodyRe,
+				wantBodyJson:    tc.want,
+			}.do(t)
+
+			_, err = os.Stat(snapshotDir)
+
+			if removed := errors.Is(err, fs.ErrNotExist); removed != tc.wantRemoved {
+				t.Errorf("Snapshot removed is %t, want %t (%v)", removed, tc.wantRemoved, err)
+			}
+		})
+	}
+}
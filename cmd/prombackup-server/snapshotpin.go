@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/pruner"
+)
+
+func (m *manager) handlePinSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		return
+	}
+
+	name, ok := m.lookupSnapshot(w, r)
+	if !ok {
+		return
+	}
+
+	m.logger.Printf("Pin snapshot %s", name)
+
+	if err := pruner.Pin(m.snapshotRootPath, name); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJsonResponse(w, http.StatusOK, nil, api.PinSnapshotResult{})
+}
+
+func (m *manager) handleUnpinSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		return
+	}
+
+	// The snapshot may already be gone, e.g. after removing it manually. A
+	// leftover marker must still be removable.
+	name, ok := snapshotNameFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	m.logger.Printf("Unpin snapshot %s", name)
+
+	if err := pruner.Unpin(m.snapshotRootPath, name); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJsonResponse(w, http.StatusOK, nil, api.UnpinSnapshotResult{})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+	"github.com/hansmi/prombackup/internal/pruner"
+)
+
+func TestPinSnapshot(t *testing.T) {
+	const snapshotName = "20221109T202035Z-355a5b4970d5a906"
+
+	for _, tc := range []struct {
+		name       string
+		missing    bool
+		pinned     bool
+		target     url.URL
+		wantCode   int
+		wantBodyRe *regexp.Regexp
+		wantPinned bool
+	}{
+		{
+			name: "pin",
+			target: url.URL{
+				Path:     apiendpoints.SnapshotPin,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode:   http.StatusOK,
+			wantPinned: true,
+		},
+		{
+			name:   "pin again",
+			pinned: true,
+			target: url.URL{
+				Path:     apiendpoints.SnapshotPin,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode:   http.StatusOK,
+			wantPinned: true,
+		},
+		{
+			name:   "unpin",
+			pinned: true,
+			target: url.URL{
+				Path:     apiendpoints.SnapshotUnpin,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "unpin not pinned",
+			target: url.URL{
+				Path:     apiendpoints.SnapshotUnpin,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "unpin removed snapshot",
+			missing: true,
+			pinned:  true,
+			target: url.URL{
+				Path:     apiendpoints.SnapshotUnpin,
+				RawQuery: "name=" + snapshotName,
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "not found",
+			target: url.URL{
+				Path:     apiendpoints.SnapshotPin,
+				RawQuery: "name=20221110T101010Z-0a1b2c3d4e5f6789",
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "bad name",
+			target: url.URL{
+				Path:     apiendpoints.SnapshotUnpin,
+				RawQuery: "name=../escape",
+			},
+			wantCode:   http.StatusBadRequest,
+			wantBodyRe: regexp.MustCompile(`(?i)^Invalid snapshot name\b`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpdir := t.TempDir()
+
+			if !tc.missing {
+				if err := os.Mkdir(filepath.Join(tmpdir, snapshotName), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tc.pinned {
+				if err := pruner.Pin(tmpdir, snapshotName); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			m, err := newManager(managerOptions{
+				snapshotDir: tmpdir,
+			})
+			if err != nil {
+				t.Fatalf("newManager() failed: %v", err)
+			}
+
+			handlerTest{
+				handler:        newRouter(m, nil),
+				method:         http.MethodPost,
+				target:         tc.target,
+				wantStatusCode: tc.wantCode,
+				wantBodyMatch:  tc.wantBodyRe,
+			}.do(t)
+
+			if pinned, err := pruner.IsPinned(tmpdir, snapshotName); err != nil {
+				t.Errorf("IsPinned() failed: %v", err)
+			} else if pinned != tc.wantPinned {
+				t.Errorf("Snapshot pinned is %t, want %t", pinned, tc.wantPinned)
+			}
+		})
+	}
+}
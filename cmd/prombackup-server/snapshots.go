@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/pruner"
+)
+
+// snapshotInfo gathers information about a single snapshot directory. Every
+// top-level directory containing a "meta.json" file is counted as a TSDB
+// block.
+func snapshotInfo(root fs.FS, name string) (api.SnapshotInfo, error) {
+	info := api.SnapshotInfo{
+		Name: name,
+	}
+
+	if ts, err := pruner.ParseTimestamp(name); err == nil {
+		info.CreatedAt = ts
+	}
+
+	err := fs.WalkDir(root, name, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		info.TotalSize += fi.Size()
+
+		if d.Name() == "meta.json" && path.Dir(path.Dir(p)) == name {
+			info.BlockCount++
+		}
+
+		return nil
+	})
+
+	return info, err
+}
+
+// snapshotNameFromRequest validates the snapshot name given in the request
+// without checking whether the snapshot exists. An error response is written
+// on failure.
+func snapshotNameFromRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.URL.Query().Get("name")
+
+	if name == "" {
+		http.Error(w, "Snapshot name is required", http.StatusNotFound)
+		return "", false
+	} else if err := validateSnapshotName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+
+	return name, true
+}
+
+// lookupSnapshot validates the snapshot name given in the request and ensures
+// the snapshot exists. An error response is written on failure.
+func (m *manager) lookupSnapshot(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name, ok := snapshotNameFromRequest(w, r)
+	if !ok {
+		return "", false
+	}
+
+	if fi, err := fs.Stat(m.snapshotRoot, name); err != nil {
+		code := http.StatusInternalServerError
+
+		if errors.Is(err, fs.ErrNotExist) {
+			code = http.StatusNotFound
+		}
+
+		http.Error(w, err.Error(), code)
+		return "", false
+	} else if !fi.IsDir() {
+		http.Error(w, fmt.Sprintf("Snapshot %s is not a directory", name), http.StatusNotFound)
+		return "", false
+	}
+
+	return name, true
+}
+
+func (m *manager) listSnapshots() ([]api.SnapshotInfo, error) {
+	entries, err := fs.ReadDir(m.snapshotRoot, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	result := []api.SnapshotInfo{}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || validateSnapshotName(entry.Name()) != nil {
+			continue
+		}
+
+		info, err := snapshotInfo(m.snapshotRoot, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		if info.Pinned, err = pruner.IsPinned(m.snapshotRootPath, entry.Name()); err != nil {
+			return nil, err
+		}
+
+		result = append(result, info)
+	}
+
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Name < result[b].Name
+	})
+
+	return result, nil
+}
+
+func (m *manager) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		return
+	}
+
+	snapshots, err := m.listSnapshots()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJsonResponse(w, http.StatusOK, nil, api.ListSnapshotsResult{
+		Snapshots: snapshots,
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/apiendpoints"
+)
+
+func TestListSnapshots(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	for path, content := range map[string]string{
+		"20221109T202035Z-355a5b4970d5a906/01GHCZ5PJTB6DP8K8B3GZ5S9XS/meta.json":        "{}",
+		"20221109T202035Z-355a5b4970d5a906/01GHCZ5PJTB6DP8K8B3GZ5S9XS/index":            "index",
+		"20221109T202035Z-355a5b4970d5a906/01GHCZ5PJTB6DP8K8B3GZ5S9XS/chunks/000001":    "chunk data",
+		"20221109T202035Z-355a5b4970d5a906/01GHCZ7KJ0XW5Z9XGZMBM6Q9XH/meta.json":        "{}",
+		"20221109T202035Z-355a5b4970d5a906/01GHCZ7KJ0XW5Z9XGZMBM6Q9XH/chunks/meta.json": "",
+		"20221110T101010Z-0a1b2c3d4e5f6789/.keep":                                       "",
+		"not_a_snapshot/01GHCZ5PJTB6DP8K8B3GZ5S9XS/meta.json":                           "{}",
+		"file-123": "",
+		"20221110T101010Z-0a1b2c3d4e5f6789.pinned": "",
+	} {
+		path = filepath.Join(tmpdir, filepath.FromSlash(path))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name       string
+		method     string
+		snapshots  string
+		target     url.URL
+		wantCode   int
+		wantBodyRe *regexp.Regexp
+		want       *api.ListSnapshotsResult
+	}{
+		{
+			name:      "success",
+			snapshots: tmpdir,
+			target: url.URL{
+				Path: apiendpoints.Snapshots,
+			},
+			wantCode: http.StatusOK,
+			want: &api.ListSnapshotsResult{
+				Snapshots: []api.SnapshotInfo{
+					{
+						Name:       "20221109T202035Z-355a5b4970d5a906",
+						CreatedAt:  time.Date(2022, 11, 9, 20, 20, 35, 0, time.UTC),
+						TotalSize:  19,
+						BlockCount: 2,
+					},
+					{
+						Name:      "20221110T101010Z-0a1b2c3d4e5f6789",
+						CreatedAt: time.Date(2022, 11, 10, 10, 10, 10, 0, time.UTC),
+						Pinned:    true,
+					},
+				},
+			},
+		},
+		{
+			name:      "empty",
+			snapshots: t.TempDir(),
+			target: url.URL{
+				Path: apiendpoints.Snapshots,
+			},
+			wantCode: http.StatusOK,
+			want: &api.ListSnapshotsResult{
+				Snapshots: []api.SnapshotInfo{},
+			},
+		},
+		{
+			name:      "missing directory",
+			snapshots: filepath.Join(tmpdir, "missing"),
+			target: url.URL{
+				Path: apiendpoints.Snapshots,
+			},
+			wantCode:   http.StatusInternalServerError,
+			wantBodyRe: regexp.MustCompile(`(?i)\bno such file\b`),
+		},
+		{
+			name:      "wrong method",
+			method:    http.MethodPost,
+			snapshots: tmpdir,
+			target: url.URL{
+				Path: apiendpoints.Snapshots,
+			},
+			wantCode:   http.StatusMethodNotAllowed,
+			wantBodyRe: regexp.MustCompile(`(?i)^Method\b`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newManager(managerOptions{
+				snapshotDir: tc.snapshots,
+			})
+			if err != nil {
+				t.Fatalf("newManager() failed: %v", err)
+			}
+
+			handlerTest{
+				handler:        newRouter(m, nil),
+				method:         tc.method,
+				target:         tc.target,
+				wantStatusCode: tc.wantCode,
+				wantBodyMatch:  tc.wantBodyRe,
+				wantBodyJson:   tc.want,
+			}.do(t)
+		})
+	}
+}
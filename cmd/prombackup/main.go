@@ -11,8 +11,18 @@ import (
 	"github.com/hansmi/prombackup/api"
 	"github.com/hansmi/prombackup/client"
 	"github.com/hansmi/prombackup/internal/clientcli"
+	"github.com/hansmi/prombackup/internal/clientcli/cancel"
 	"github.com/hansmi/prombackup/internal/clientcli/create"
+	"github.com/hansmi/prombackup/internal/clientcli/decrypt"
+	"github.com/hansmi/prombackup/internal/clientcli/delete"
+	"github.com/hansmi/prombackup/internal/clientcli/download"
+	"github.com/hansmi/prombackup/internal/clientcli/downloads"
+	"github.com/hansmi/prombackup/internal/clientcli/list"
+	"github.com/hansmi/prombackup/internal/clientcli/pin"
 	"github.com/hansmi/prombackup/internal/clientcli/prune"
+	"github.com/hansmi/prombackup/internal/clientcli/status"
+	"github.com/hansmi/prombackup/internal/clientcli/unpin"
+	"github.com/hansmi/prombackup/internal/clientcli/verify"
 )
 
 func main() {
@@ -24,7 +34,17 @@ func main() {
 	subcommands.Register(subcommands.FlagsCommand(), "")
 	subcommands.Register(subcommands.CommandsCommand(), "")
 	subcommands.Register(&create.Command{}, "")
+	subcommands.Register(&download.Command{}, "")
+	subcommands.Register(&delete.Command{}, "")
+	subcommands.Register(&list.Command{}, "")
+	subcommands.Register(&pin.Command{}, "")
+	subcommands.Register(&unpin.Command{}, "")
+	subcommands.Register(&status.Command{}, "")
+	subcommands.Register(&downloads.Command{}, "")
+	subcommands.Register(&cancel.Command{}, "")
 	subcommands.Register(&prune.Command{}, "")
+	subcommands.Register(&decrypt.Command{}, "")
+	subcommands.Register(&verify.Command{}, "")
 
 	flag.Parse()
 
@@ -34,6 +54,8 @@ func main() {
 	}
 
 	rt := &clientcli.Runtime{
+		ServerURL: *serverURL,
+
 		NewClient: func() (api.Interface, error) {
 			if *serverURL == "" {
 				return nil, errors.New("missing top-level -server flag")
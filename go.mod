@@ -3,15 +3,22 @@ module github.com/hansmi/prombackup
 go 1.25.0
 
 require (
+	filippo.io/age v1.2.1
+	github.com/dsnet/compress v0.0.1
 	github.com/google/go-cmp v0.7.0
 	github.com/google/subcommands v1.2.0
 	github.com/gorilla/handlers v1.5.2
 	github.com/gorilla/mux v1.8.1
 	github.com/klauspost/compress v1.19.0
+	github.com/klauspost/pgzip v1.2.6
 	github.com/minio/sha256-simd v1.0.1
+	github.com/pierrec/lz4/v4 v4.1.31
 	github.com/prometheus/client_golang v1.23.2
 	github.com/prometheus/common v0.69.0
+	github.com/ulikunitz/xz v0.5.17
+	github.com/zeebo/blake3 v0.2.4
 	go.uber.org/multierr v1.11.0
+	golang.org/x/sys v0.45.0
 )
 
 require (
@@ -20,11 +27,12 @@ require (
 	github.com/felixge/httpsnoop v1.0.4 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
 	github.com/klauspost/cpuid/v2 v2.2.7 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/prometheus/client_model v0.6.2 // indirect
 	github.com/prometheus/procfs v0.16.1 // indirect
-	golang.org/x/sys v0.45.0 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
 )
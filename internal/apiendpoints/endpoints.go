@@ -2,7 +2,13 @@ package apiendpoints
 
 const (
 	Snapshot       = "/api/snapshot"
+	SnapshotPin    = "/api/snapshot/pin"
+	SnapshotUnpin  = "/api/snapshot/unpin"
+	Snapshots      = "/api/snapshots"
 	Download       = "/api/download"
+	DownloadCancel = "/api/download/cancel"
 	DownloadStatus = "/api/download_status"
+	Downloads      = "/api/downloads"
 	Prune          = "/api/prune"
+	SigningKey     = "/api/signing_key"
 )
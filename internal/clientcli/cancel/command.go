@@ -0,0 +1,73 @@
+package cancel
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/google/subcommands"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+)
+
+var errIDRequired = errors.New("download ID is required")
+
+type ClientInterface interface {
+	CancelDownload(context.Context, api.CancelDownloadOptions) (*api.CancelDownloadResult, error)
+}
+
+type Command struct {
+	id string
+}
+
+func (*Command) Name() string {
+	return "cancel"
+}
+
+func (*Command) Synopsis() string {
+	return `Cancel a running download.`
+}
+
+func (c *Command) Usage() string {
+	return ``
+}
+
+func (c *Command) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.id, "id", "",
+		"Download ID as reported by the server.")
+}
+
+func (c *Command) execute(ctx context.Context, cl ClientInterface) error {
+	if c.id == "" {
+		return errIDRequired
+	}
+
+	if _, err := cl.CancelDownload(ctx, api.CancelDownloadOptions{
+		ID: c.id,
+	}); err != nil {
+		return err
+	}
+
+	log.Printf("Download %s cancelled", c.id)
+
+	return nil
+}
+
+func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	r := args[0].(*clientcli.Runtime)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	if err := r.WithClient(func(cl api.Interface) error {
+		return c.execute(ctx, cl)
+	}); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
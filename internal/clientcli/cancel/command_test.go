@@ -0,0 +1,86 @@
+package cancel
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/testutils"
+)
+
+var errTest = errors.New("test error")
+
+type fakeClient struct {
+	gotOptions api.CancelDownloadOptions
+	err        error
+}
+
+func (c *fakeClient) CancelDownload(ctx context.Context, opts api.CancelDownloadOptions) (*api.CancelDownloadResult, error) {
+	c.gotOptions = opts
+
+	return &api.CancelDownloadResult{}, c.err
+}
+
+func TestCommand(t *testing.T) {
+	defer testutils.LogOutput(t, io.Discard)()
+
+	for _, tc := range []struct {
+		name        string
+		args        []string
+		client      *fakeClient
+		wantErr     error
+		wantOptions api.CancelDownloadOptions
+	}{
+		{
+			name:    "missing ID",
+			client:  &fakeClient{},
+			wantErr: errIDRequired,
+		},
+		{
+			name:   "success",
+			args:   []string{"-id", "20221109202035_1a3f8c9d2"},
+			client: &fakeClient{},
+			wantOptions: api.CancelDownloadOptions{
+				ID: "20221109202035_1a3f8c9d2",
+			},
+		},
+		{
+			name: "error",
+			args: []string{"-id", "finished-123"},
+			client: &fakeClient{
+				err: errTest,
+			},
+			wantErr: errTest,
+			wantOptions: api.CancelDownloadOptions{
+				ID: "finished-123",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+
+			var c Command
+
+			c.SetFlags(fs)
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Errorf("Flag parsing failed: %v", err)
+			}
+
+			err := c.execute(context.Background(), tc.client)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.wantOptions, tc.client.gotOptions); diff != "" {
+				t.Errorf("Options diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
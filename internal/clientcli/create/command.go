@@ -2,32 +2,31 @@ package create
 
 import (
 	"context"
-	"encoding/hex"
 	"errors"
 	"flag"
-	"fmt"
-	"io"
 	"log"
 
 	"github.com/google/subcommands"
 	"github.com/hansmi/prombackup/api"
 	"github.com/hansmi/prombackup/internal/clientcli"
-	"github.com/minio/sha256-simd"
 	"go.uber.org/multierr"
 )
 
-var ErrDownloadFailed = errors.New("download failed")
+var ErrDownloadFailed = clientcli.ErrDownloadFailed
+
+var errSidecarStdout = errors.New("checksum and metadata files can't be written when the archive is written to standard output")
 
 type ClientInterface interface {
+	clientcli.DownloadClient
 	Snapshot(context.Context, api.SnapshotOptions) (*api.SnapshotResult, error)
-	Download(context.Context, api.DownloadOptions) (*api.DownloadResult, error)
-	DownloadStatus(context.Context, api.DownloadStatusOptions) (*api.DownloadStatus, error)
 }
 
 type Command struct {
-	outputPath string
-	format     string
-	skipHead   bool
+	download clientcli.Downloader
+	skipHead bool
+
+	checksumFile bool
+	metadataFile bool
 }
 
 func (*Command) Name() string {
@@ -43,22 +42,27 @@ func (c *Command) Usage() string {
 }
 
 func (c *Command) SetFlags(fs *flag.FlagSet) {
-	fs.StringVar(&c.outputPath, "output", "",
-		`Path to file for downloaded archive. "-" for standard output. Defaults to filename from remote side.`)
-	fs.StringVar(&c.format, "format", api.ArchiveTar.Name(),
-		fmt.Sprintf(`Archive format to request. One of %q.`, api.ArchiveFormatAll))
+	c.download.SetFlags(fs)
 	fs.BoolVar(&c.skipHead, "skip_head", false,
 		"Skip data present in the head block.")
+	fs.BoolVar(&c.checksumFile, "checksum_file", false,
+		"Write the SHA256 checksum to a file next to the archive ("+clientcli.ChecksumFileExtension+" extension). The file can be checked using \"sha256sum --check\".")
+	fs.BoolVar(&c.metadataFile, "metadata_file", false,
+		"Write information about the snapshot and download to a JSON file next to the archive ("+clientcli.MetadataFileExtension+" extension).")
 }
 
-func (c *Command) execute(ctx context.Context, cl ClientInterface) (err error) {
-	output, err := clientcli.NewOutputFile(c.outputPath)
+func (c *Command) execute(ctx context.Context, cl ClientInterface, server string) (err error) {
+	output, err := c.download.NewOutputFile()
 	if err != nil {
 		return err
 	}
 
 	defer multierr.AppendInvoke(&err, multierr.Close(output))
 
+	if (c.checksumFile || c.metadataFile) && output.IsStdout() {
+		return errSidecarStdout
+	}
+
 	snapshot, err := cl.Snapshot(ctx, api.SnapshotOptions{
 		SkipHead: c.skipHead,
 	})
@@ -66,43 +70,26 @@ func (c *Command) execute(ctx context.Context, cl ClientInterface) (err error) {
 		return err
 	}
 
-	digestw := sha256.New()
-
-	download, err := cl.Download(ctx, api.DownloadOptions{
-		SnapshotName: snapshot.Name,
-		Format:       api.ArchiveFormat(c.format),
-		BodyWriter: func(result api.DownloadResult) (io.Writer, error) {
-			w, err := output.Open(result.Filename)
-			if err != nil {
-				return nil, fmt.Errorf("opening output: %w", err)
-			}
-
-			if namer, ok := w.(interface{ Name() string }); ok && err == nil {
-				log.Printf("Writing snapshot archive to %s", namer.Name())
-			}
-
-			return io.MultiWriter(w, digestw), err
-		},
-	})
+	status, err := c.download.Download(ctx, cl, output, snapshot.Name)
 	if err != nil {
 		return err
 	}
 
-	status, err := cl.DownloadStatus(ctx, api.DownloadStatusOptions{
-		ID: download.ID,
-	})
-	if err != nil {
+	// Sidecar files must only describe a complete archive.
+	if err := output.Close(); err != nil {
 		return err
 	}
 
-	if sf := status.Finished; sf == nil {
-		return fmt.Errorf("download not finished: %+v", status)
-	} else if sf.ErrorText != nil {
-		return fmt.Errorf("%w: %s", ErrDownloadFailed, *sf.ErrorText)
-	} else if !sf.Success {
-		return ErrDownloadFailed
-	} else if want := hex.EncodeToString(digestw.Sum(nil)); sf.Sha256Hex != want {
-		return fmt.Errorf("%w: SHA256 checksum mismatch (got %s, want %s)", ErrDownloadFailed, sf.Sha256Hex, want)
+	if c.checksumFile {
+		if err := clientcli.WriteChecksumFile(output.Name(), status.Finished.Sha256Hex); err != nil {
+			return err
+		}
+	}
+
+	if c.metadataFile {
+		if err := clientcli.WriteMetadataFile(output.Name(), clientcli.NewMetadata(status, server, output.Name())); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -117,7 +104,7 @@ func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) su
 	}
 
 	if err := r.WithClient(func(cl api.Interface) error {
-		return c.execute(ctx, cl)
+		return c.execute(ctx, cl, r.ServerURL)
 	}); err != nil {
 		log.Printf("Error: %v", err)
 		return subcommands.ExitFailure
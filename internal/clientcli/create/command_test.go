@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -25,6 +26,8 @@ type fakeClient struct {
 	downloadBody   string
 	downloadResult api.DownloadResult
 	downloadStatus api.DownloadStatus
+
+	gotDownloadOptions api.DownloadOptions
 }
 
 func (c *fakeClient) Snapshot(context.Context, api.SnapshotOptions) (*api.SnapshotResult, error) {
@@ -32,6 +35,8 @@ func (c *fakeClient) Snapshot(context.Context, api.SnapshotOptions) (*api.Snapsh
 }
 
 func (c *fakeClient) Download(ctx context.Context, opts api.DownloadOptions) (*api.DownloadResult, error) {
+	c.gotDownloadOptions = opts
+
 	if w, err := opts.BodyWriter(c.downloadResult); err != nil {
 		return nil, fmt.Errorf("BodyWriter() failed: %v", err)
 	} else if _, err := io.WriteString(w, c.downloadBody); err != nil {
@@ -57,6 +62,9 @@ func TestCommand(t *testing.T) {
 		wantErr      error
 		readBodyFrom string
 		wantBody     string
+
+		wantDownloadOptions *api.DownloadOptions
+		wantSidecars        map[string]string
 	}{
 		{
 			name: "success",
@@ -117,6 +125,125 @@ func TestCommand(t *testing.T) {
 			readBodyFrom: outputFile,
 			wantBody:     "test body for file",
 		},
+		{
+			name: "compression options",
+			args: []string{
+				"-format", "tzst",
+				"-level", "19",
+				"-window", "16777216",
+				"-concurrency", "2",
+			},
+			client: &fakeClient{
+				snapshotResult: api.SnapshotResult{
+					Name: "compressed",
+				},
+				downloadBody: "compressed",
+				downloadResult: api.DownloadResult{
+					Filename: "compressed.tar.zst",
+				},
+				downloadStatus: api.DownloadStatus{
+					Finished: &api.DownloadStatusFinished{
+						Success:   true,
+						Sha256Hex: "9da308c2e4bc33afa72df5c088b5fc5673c477f3ef21d6bdaa358393834f9804",
+					},
+				},
+			},
+			readBodyFrom: "compressed.tar.zst",
+			wantBody:     "compressed",
+			wantDownloadOptions: &api.DownloadOptions{
+				SnapshotName:           "compressed",
+				Format:                 api.ArchiveTarZstd,
+				CompressionLevel:       19,
+				CompressionWindow:      16777216,
+				CompressionConcurrency: 2,
+			},
+		},
+		{
+			name: "additional digest",
+			args: []string{"-digest", "blake3"},
+			client: &fakeClient{
+				snapshotResult: api.SnapshotResult{
+					Name: "digests",
+				},
+				downloadBody: "digests",
+				downloadResult: api.DownloadResult{
+					Filename: "digests.tar",
+				},
+				downloadStatus: api.DownloadStatus{
+					Finished: &api.DownloadStatusFinished{
+						Success:   true,
+						Sha256Hex: "112bcac13dc8c5bf2b1d38f6775b1931e1bb8b395a68c0b4299225e35e026c81",
+						Digests: map[api.DigestAlgorithm]string{
+							api.DigestSha256: "112bcac13dc8c5bf2b1d38f6775b1931e1bb8b395a68c0b4299225e35e026c81",
+							api.DigestBlake3: "0bfe74e7859815640e736a106c3ebef4d2977a2ca122cbc26d975cc61549b0e5",
+						},
+					},
+				},
+			},
+			readBodyFrom: "digests.tar",
+			wantBody:     "digests",
+			wantDownloadOptions: &api.DownloadOptions{
+				SnapshotName: "digests",
+				Format:       api.ArchiveTar,
+				Digests:      []api.DigestAlgorithm{api.DigestBlake3},
+			},
+		},
+		{
+			name: "additional digest mismatch",
+			args: []string{"-digest", "sha512"},
+			client: &fakeClient{
+				downloadBody: "digests",
+				downloadResult: api.DownloadResult{
+					Filename: "mismatch.tar",
+				},
+				downloadStatus: api.DownloadStatus{
+					Finished: &api.DownloadStatusFinished{
+						Success:   true,
+						Sha256Hex: "112bcac13dc8c5bf2b1d38f6775b1931e1bb8b395a68c0b4299225e35e026c81",
+						Digests: map[api.DigestAlgorithm]string{
+							api.DigestSha512: "0000",
+						},
+					},
+				},
+			},
+			wantErr: ErrDownloadFailed,
+		},
+		{
+			name: "sidecars",
+			args: []string{"-checksum_file", "-metadata_file"},
+			client: &fakeClient{
+				snapshotResult: api.SnapshotResult{
+					Name: "sidecars",
+				},
+				downloadBody: "test body",
+				downloadResult: api.DownloadResult{
+					Filename: "sidecars.tar",
+				},
+				downloadStatus: api.DownloadStatus{
+					ID:           "1667766556_abc",
+					SnapshotName: "sidecars",
+					Format:       api.ArchiveTar,
+					Finished: &api.DownloadStatusFinished{
+						Success:   true,
+						Sha256Hex: "63efb315ed71cc7e5a1fc202434bb3aec2091e7838707e148a017faebb7464fe",
+					},
+				},
+			},
+			readBodyFrom: "sidecars.tar",
+			wantBody:     "test body",
+			wantSidecars: map[string]string{
+				"sidecars.tar.sha256": "63efb315ed71cc7e5a1fc202434bb3aec2091e7838707e148a017faebb7464fe  sidecars.tar\n",
+				"sidecars.tar.json":   `"download_id": "1667766556_abc"`,
+			},
+		},
+		{
+			name: "sidecars with stdout",
+			args: []string{"-output", "-", "-checksum_file"},
+			client: &fakeClient{
+				snapshotError: errTest,
+			},
+			wantErr: errSidecarStdout,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			defer testutils.Chdir(t, t.TempDir())()
@@ -131,12 +258,18 @@ func TestCommand(t *testing.T) {
 				t.Errorf("Flag parsing failed: %v", err)
 			}
 
-			err := c.execute(context.Background(), tc.client)
+			err := c.execute(context.Background(), tc.client, "http://prometheus:8080")
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
 			}
 
+			if tc.wantDownloadOptions != nil {
+				if diff := cmp.Diff(*tc.wantDownloadOptions, tc.client.gotDownloadOptions, cmpopts.IgnoreFields(api.DownloadOptions{}, "BodyWriter")); diff != "" {
+					t.Errorf("Download options diff (-want +got):\n%s", diff)
+				}
+			}
+
 			if tc.readBodyFrom != "" {
 				if content, err := os.ReadFile(tc.readBodyFrom); err != nil {
 					t.Errorf("ReadFile() failed: %v", err)
@@ -144,6 +277,14 @@ func TestCommand(t *testing.T) {
 					t.Errorf("Body diff (-want +got):\n%s", diff)
 				}
 			}
+
+			for path, want := range tc.wantSidecars {
+				if content, err := os.ReadFile(path); err != nil {
+					t.Errorf("ReadFile() failed: %v", err)
+				} else if !strings.Contains(string(content), want) {
+					t.Errorf("File %s doesn't contain %q:\n%s", path, want, content)
+				}
+			}
 		})
 	}
 }
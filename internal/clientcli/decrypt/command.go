@@ -0,0 +1,141 @@
+package decrypt
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/google/subcommands"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+	"go.uber.org/multierr"
+)
+
+var errIdentityRequired = errors.New("identity file is required")
+var errOutputRequired = errors.New("output path is required when the input name doesn't end in " + api.EncryptedFileExtension)
+
+type Command struct {
+	identityPath string
+	inputPath    string
+	outputPath   string
+}
+
+func (*Command) Name() string {
+	return "decrypt"
+}
+
+func (*Command) Synopsis() string {
+	return `Decrypt an encrypted snapshot archive.`
+}
+
+func (c *Command) Usage() string {
+	return ``
+}
+
+func (c *Command) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.identityPath, "identity", "",
+		`Path to file with age identities (private keys), e.g. as generated by "age-keygen".`)
+	fs.StringVar(&c.inputPath, "input", "-",
+		`Path to encrypted archive. "-" for standard input.`)
+	fs.StringVar(&c.outputPath, "output", "",
+		fmt.Sprintf(`Path to file for decrypted archive. "-" for standard output. Defaults to the input name without the %q extension.`, api.EncryptedFileExtension))
+}
+
+func readIdentities(path string) ([]age.Identity, error) {
+	if path == "" {
+		return nil, errIdentityRequired
+	}
+
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fh.Close()
+
+	return age.ParseIdentities(fh)
+}
+
+// defaultOutputPath returns the output path, derived from the input path if
+// not given explicitly.
+func (c *Command) defaultOutputPath() (string, error) {
+	if c.outputPath != "" {
+		return c.outputPath, nil
+	}
+
+	if c.inputPath != "-" && strings.HasSuffix(c.inputPath, api.EncryptedFileExtension) {
+		return strings.TrimSuffix(c.inputPath, api.EncryptedFileExtension), nil
+	}
+
+	return "", errOutputRequired
+}
+
+func decrypt(identities []age.Identity, r io.Reader, w io.Writer) error {
+	dr, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, dr)
+
+	return err
+}
+
+func (c *Command) execute(stdin io.Reader) (err error) {
+	outputPath, err := c.defaultOutputPath()
+	if err != nil {
+		return err
+	}
+
+	identities, err := readIdentities(c.identityPath)
+	if err != nil {
+		return fmt.Errorf("reading identities: %w", err)
+	}
+
+	r := stdin
+
+	if c.inputPath != "-" {
+		fh, err := os.Open(c.inputPath)
+		if err != nil {
+			return err
+		}
+
+		defer fh.Close()
+
+		r = fh
+	}
+
+	output, err := clientcli.NewOutputFile(outputPath)
+	if err != nil {
+		return err
+	}
+
+	defer multierr.AppendInvoke(&err, multierr.Close(output))
+
+	w, err := output.Open("")
+	if err != nil {
+		return err
+	}
+
+	return decrypt(identities, r, w)
+}
+
+func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	if err := c.execute(os.Stdin); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,138 @@
+package decrypt
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func encrypt(t *testing.T, recipient age.Recipient, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+
+	if _, err := io.WriteString(w, content); err != nil {
+		t.Fatalf("WriteString() failed: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func writeIdentity(t *testing.T, path string) *age.X25519Identity {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(identity.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return identity
+}
+
+func TestCommand(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	identityPath := filepath.Join(tmpdir, "identity.txt")
+	identity := writeIdentity(t, identityPath)
+	otherIdentityPath := filepath.Join(tmpdir, "other.txt")
+	writeIdentity(t, otherIdentityPath)
+
+	const content = "archive content"
+
+	inputPath := filepath.Join(tmpdir, "snapshot.tar.gz.age")
+
+	if err := os.WriteFile(inputPath, encrypt(t, identity.Recipient(), content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		stdin    []byte
+		wantErr  error
+		wantPath string
+
+		wantNoIdentityMatch bool
+	}{
+		{
+			name:     "default output",
+			args:     []string{"-identity", identityPath, "-input", inputPath},
+			wantPath: filepath.Join(tmpdir, "snapshot.tar.gz"),
+		},
+		{
+			name:     "stdin",
+			args:     []string{"-identity", identityPath, "-output", filepath.Join(tmpdir, "stdin.tar")},
+			stdin:    encrypt(t, identity.Recipient(), content),
+			wantPath: filepath.Join(tmpdir, "stdin.tar"),
+		},
+		{
+			name:    "missing identity",
+			args:    []string{"-input", inputPath},
+			wantErr: errIdentityRequired,
+		},
+		{
+			name:    "output required",
+			args:    []string{"-identity", identityPath},
+			wantErr: errOutputRequired,
+		},
+		{
+			name: "wrong identity",
+			args: []string{"-identity", otherIdentityPath, "-input", inputPath, "-output", filepath.Join(tmpdir, "wrong.tar")},
+
+			wantNoIdentityMatch: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+
+			var c Command
+
+			c.SetFlags(fs)
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Errorf("Flag parsing failed: %v", err)
+			}
+
+			err := c.execute(bytes.NewReader(tc.stdin))
+
+			var noMatchErr *age.NoIdentityMatchError
+
+			if tc.wantNoIdentityMatch {
+				if !errors.As(err, &noMatchErr) {
+					t.Errorf("execute() returned %v, want %T", err, noMatchErr)
+				}
+			} else if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if tc.wantPath != "" {
+				if got, err := os.ReadFile(tc.wantPath); err != nil {
+					t.Errorf("ReadFile() failed: %v", err)
+				} else if diff := cmp.Diff(content, string(got)); diff != "" {
+					t.Errorf("Output diff (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
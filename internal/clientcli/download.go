@@ -0,0 +1,220 @@
+package clientcli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/digest"
+)
+
+var ErrDownloadFailed = errors.New("download failed")
+var ErrSignature = errors.New("signature verification failed")
+
+// SignatureFileExtension is appended to the archive filename to store its
+// detached signature.
+const SignatureFileExtension = ".sig"
+
+type DownloadClient interface {
+	Download(context.Context, api.DownloadOptions) (*api.DownloadResult, error)
+	DownloadStatus(context.Context, api.DownloadStatusOptions) (*api.DownloadStatus, error)
+}
+
+// Downloader implements the flags and logic shared by commands downloading
+// a snapshot archive. The archive checksum is verified against the status
+// reported by the server.
+type Downloader struct {
+	outputPath string
+	format     string
+
+	compressionLevel       int
+	compressionWindow      int
+	compressionConcurrency int
+
+	recipients []string
+	verifyKey  ed25519.PublicKey
+	digests    []api.DigestAlgorithm
+}
+
+func (d *Downloader) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&d.outputPath, "output", "",
+		`Path to file for downloaded archive. "-" for standard output. Defaults to filename from remote side.`)
+	fs.StringVar(&d.format, "format", api.ArchiveTar.Name(),
+		fmt.Sprintf(`Archive format to request. One of %q.`, api.ArchiveFormatAll))
+	fs.IntVar(&d.compressionLevel, "level", 0,
+		"Compression level to request. The valid range depends on the format, e.g. 1 to 9 for gzip or 1 to 22 for Zstandard. Zero uses the server default.")
+	fs.IntVar(&d.compressionWindow, "window", 0,
+		"Zstandard window size in bytes to request. Must be a power of two. Zero uses the server default.")
+	fs.IntVar(&d.compressionConcurrency, "concurrency", 0,
+		"Number of encoder goroutines to request for parallel gzip or Zstandard compression. Zero uses the server default.")
+	fs.Func("recipient",
+		`Public key of an age recipient ("age1...") to encrypt the archive to. Can be given multiple times. Use the "decrypt" command to decrypt the archive.`,
+		func(value string) error {
+			d.recipients = append(d.recipients, value)
+			return nil
+		})
+	fs.Func("digest",
+		fmt.Sprintf(`Additional digest algorithm to request and verify. One of %q. Can be given multiple times. SHA256 is always verified.`, api.DigestAlgorithmAll),
+		func(value string) error {
+			a := api.DigestAlgorithm(value)
+
+			if _, err := digest.New(a); err != nil {
+				return err
+			}
+
+			d.digests = append(d.digests, a)
+
+			return nil
+		})
+	fs.Func("verify_key",
+		`Hex-encoded Ed25519 public key of the server. If given the download fails unless the archive signature is valid.`,
+		func(value string) error {
+			key, err := hex.DecodeString(value)
+			if err != nil {
+				return err
+			}
+
+			if len(key) != ed25519.PublicKeySize {
+				return fmt.Errorf("public key must be %d bytes long, got %d", ed25519.PublicKeySize, len(key))
+			}
+
+			d.verifyKey = key
+
+			return nil
+		})
+}
+
+// NewOutputFile prepares the output file. Callers should invoke it before
+// doing any expensive work so that unusable paths are reported early.
+func (d *Downloader) NewOutputFile() (*OutputFile, error) {
+	return NewOutputFile(d.outputPath)
+}
+
+// verifyDownload checks the server status against the locally computed
+// digests. Every digest must match the one reported by the server.
+func verifyDownload(status *api.DownloadStatus, digests map[api.DigestAlgorithm]string) error {
+	sf := status.Finished
+
+	if sf == nil {
+		return fmt.Errorf("download not finished: %+v", status)
+	} else if sf.ErrorText != nil {
+		return fmt.Errorf("%w: %s", ErrDownloadFailed, *sf.ErrorText)
+	} else if !sf.Success {
+		return ErrDownloadFailed
+	} else if sha256Hex := digests[api.DigestSha256]; sf.Sha256Hex != sha256Hex {
+		return fmt.Errorf("%w: SHA256 checksum mismatch (got %s, want %s)", ErrDownloadFailed, sf.Sha256Hex, sha256Hex)
+	}
+
+	for a, want := range digests {
+		if a == api.DigestSha256 {
+			continue
+		}
+
+		if got, ok := sf.Digests[a]; !ok {
+			return fmt.Errorf("%w: server didn't report %s digest", ErrDownloadFailed, a)
+		} else if got != want {
+			return fmt.Errorf("%w: %s digest mismatch (got %s, want %s)", ErrDownloadFailed, a, got, want)
+		}
+	}
+
+	return nil
+}
+
+// Download fetches the archive of the named snapshot into the output file and
+// verifies the result. The final status reported by the server is returned on
+// success.
+func (d *Downloader) Download(ctx context.Context, cl DownloadClient, output *OutputFile, snapshotName string) (*api.DownloadStatus, error) {
+	digests, err := digest.NewSet(append([]api.DigestAlgorithm{api.DigestSha256}, d.digests...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	download, err := cl.Download(ctx, api.DownloadOptions{
+		SnapshotName: snapshotName,
+		Format:       api.ArchiveFormat(d.format),
+
+		CompressionLevel:       d.compressionLevel,
+		CompressionWindow:      d.compressionWindow,
+		CompressionConcurrency: d.compressionConcurrency,
+		Recipients:             d.recipients,
+		Digests:                d.digests,
+
+		BodyWriter: func(result api.DownloadResult) (io.Writer, error) {
+			w, err := output.Open(result.Filename)
+			if err != nil {
+				return nil, fmt.Errorf("opening output: %w", err)
+			}
+
+			if namer, ok := w.(interface{ Name() string }); ok && err == nil {
+				log.Printf("Writing snapshot archive to %s", namer.Name())
+			}
+
+			return io.MultiWriter(w, digests), err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := cl.DownloadStatus(ctx, api.DownloadStatusOptions{
+		ID: download.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyDownload(status, digests.HexSums()); err != nil {
+		return nil, err
+	}
+
+	if err := d.checkSignature(status.Finished, digests.Sum(api.DigestSha256), output.Name()); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// checkSignature verifies the archive signature if a public key is configured
+// and writes the signature to a file next to the archive. Signatures are
+// stored as 64 raw bytes.
+func (d *Downloader) checkSignature(sf *api.DownloadStatusFinished, sum []byte, archivePath string) error {
+	if sf.SignatureHex == "" {
+		if d.verifyKey != nil {
+			return fmt.Errorf("%w: archive is not signed", ErrSignature)
+		}
+
+		return nil
+	}
+
+	signature, err := hex.DecodeString(sf.SignatureHex)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignature, err)
+	}
+
+	if d.verifyKey != nil {
+		if !ed25519.Verify(d.verifyKey, sum, signature) {
+			return fmt.Errorf("%w: invalid signature %s", ErrSignature, sf.SignatureHex)
+		}
+
+		log.Printf("Archive signature is valid")
+	}
+
+	if archivePath != "" {
+		path := archivePath + SignatureFileExtension
+
+		if err := os.WriteFile(path, signature, 0o644); err != nil {
+			return err
+		}
+
+		log.Printf("Wrote archive signature to %s", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/google/subcommands"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+	"go.uber.org/multierr"
+)
+
+var errNameRequired = errors.New("snapshot name is required")
+
+type Command struct {
+	download clientcli.Downloader
+	name     string
+}
+
+func (*Command) Name() string {
+	return "download"
+}
+
+func (*Command) Synopsis() string {
+	return `Download an existing snapshot.`
+}
+
+func (c *Command) Usage() string {
+	return ``
+}
+
+func (c *Command) SetFlags(fs *flag.FlagSet) {
+	c.download.SetFlags(fs)
+	fs.StringVar(&c.name, "name", "",
+		"Name of the snapshot to download.")
+}
+
+func (c *Command) execute(ctx context.Context, cl clientcli.DownloadClient) (err error) {
+	if c.name == "" {
+		return errNameRequired
+	}
+
+	output, err := c.download.NewOutputFile()
+	if err != nil {
+		return err
+	}
+
+	defer multierr.AppendInvoke(&err, multierr.Close(output))
+
+	_, err = c.download.Download(ctx, cl, output, c.name)
+
+	return err
+}
+
+func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	r := args[0].(*clientcli.Runtime)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	if err := r.WithClient(func(cl api.Interface) error {
+		return c.execute(ctx, cl)
+	}); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,163 @@
+package download
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+	"github.com/hansmi/prombackup/internal/testutils"
+)
+
+type fakeClient struct {
+	downloadBody   string
+	downloadResult api.DownloadResult
+	downloadStatus api.DownloadStatus
+
+	gotOptions api.DownloadOptions
+}
+
+func (c *fakeClient) Download(ctx context.Context, opts api.DownloadOptions) (*api.DownloadResult, error) {
+	c.gotOptions = opts
+
+	if w, err := opts.BodyWriter(c.downloadResult); err != nil {
+		return nil, fmt.Errorf("BodyWriter() failed: %v", err)
+	} else if _, err := io.WriteString(w, c.downloadBody); err != nil {
+		return nil, fmt.Errorf("WriteString() failed: %v", err)
+	}
+
+	return &c.downloadResult, nil
+}
+
+func (c *fakeClient) DownloadStatus(context.Context, api.DownloadStatusOptions) (*api.DownloadStatus, error) {
+	return &c.downloadStatus, nil
+}
+
+func TestCommand(t *testing.T) {
+	defer testutils.LogOutput(t, io.Discard)()
+
+	outputFile := filepath.Join(t.TempDir(), "output.tar.gz")
+
+	for _, tc := range []struct {
+		name         string
+		args         []string
+		client       *fakeClient
+		wantErr      error
+		wantOptions  api.DownloadOptions
+		readBodyFrom string
+		wantBody     string
+	}{
+		{
+			name:    "missing name",
+			client:  &fakeClient{},
+			wantErr: errNameRequired,
+		},
+		{
+			name: "success",
+			args: []string{"-name", "20221109T202035Z-355a5b4970d5a906"},
+			client: &fakeClient{
+				downloadBody: "test body",
+				downloadResult: api.DownloadResult{
+					Filename: "success.tar",
+				},
+				downloadStatus: api.DownloadStatus{
+					Finished: &api.DownloadStatusFinished{
+						Success:   true,
+						Sha256Hex: "63efb315ed71cc7e5a1fc202434bb3aec2091e7838707e148a017faebb7464fe",
+					},
+				},
+			},
+			wantOptions: api.DownloadOptions{
+				SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+				Format:       api.ArchiveTar,
+			},
+			readBodyFrom: "success.tar",
+			wantBody:     "test body",
+		},
+		{
+			name: "format and output",
+			args: []string{
+				"-name", "20221110T101010Z-0a1b2c3d4e5f6789",
+				"-format", "tgz",
+				"-output", outputFile,
+			},
+			client: &fakeClient{
+				downloadBody: "test body for file",
+				downloadStatus: api.DownloadStatus{
+					Finished: &api.DownloadStatusFinished{
+						Success:   true,
+						Sha256Hex: "677ad9085d46959bccc7aa5f433efc383cf39a3d459099d4455e34e52505c7bf",
+					},
+				},
+			},
+			wantOptions: api.DownloadOptions{
+				SnapshotName: "20221110T101010Z-0a1b2c3d4e5f6789",
+				Format:       api.ArchiveTarGzip,
+			},
+			readBodyFrom: outputFile,
+			wantBody:     "test body for file",
+		},
+		{
+			name: "checksum mismatch",
+			args: []string{"-name", "mismatch-123"},
+			client: &fakeClient{
+				downloadBody: "corrupted",
+				downloadResult: api.DownloadResult{
+					Filename: "mismatch.tar",
+				},
+				downloadStatus: api.DownloadStatus{
+					Finished: &api.DownloadStatusFinished{
+						Success:   true,
+						Sha256Hex: "0000",
+					},
+				},
+			},
+			wantErr: clientcli.ErrDownloadFailed,
+			wantOptions: api.DownloadOptions{
+				SnapshotName: "mismatch-123",
+				Format:       api.ArchiveTar,
+			},
+			readBodyFrom: "mismatch.tar",
+			wantBody:     "corrupted",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer testutils.Chdir(t, t.TempDir())()
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+
+			var c Command
+
+			c.SetFlags(fs)
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Errorf("Flag parsing failed: %v", err)
+			}
+
+			err := c.execute(context.Background(), tc.client)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.wantOptions, tc.client.gotOptions, cmpopts.IgnoreFields(api.DownloadOptions{}, "BodyWriter")); diff != "" {
+				t.Errorf("Download options diff (-want +got):\n%s", diff)
+			}
+
+			if tc.readBodyFrom != "" {
+				if content, err := os.ReadFile(tc.readBodyFrom); err != nil {
+					t.Errorf("ReadFile() failed: %v", err)
+				} else if diff := cmp.Diff(tc.wantBody, string(content)); diff != "" {
+					t.Errorf("Body diff (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package clientcli
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/ref"
+	"github.com/hansmi/prombackup/internal/testutils"
+)
+
+func TestVerifyDownload(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		status  api.DownloadStatus
+		digests map[api.DigestAlgorithm]string
+		wantErr error
+	}{
+		{
+			name:    "not finished",
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "success",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{
+					Success:   true,
+					Sha256Hex: "abcd",
+				},
+			},
+			digests: map[api.DigestAlgorithm]string{api.DigestSha256: "abcd"},
+		},
+		{
+			name: "error text",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{
+					ErrorText: ref.Ref("test error"),
+				},
+			},
+			wantErr: ErrDownloadFailed,
+		},
+		{
+			name: "unsuccessful",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{},
+			},
+			wantErr: ErrDownloadFailed,
+		},
+		{
+			name: "checksum mismatch",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{
+					Success:   true,
+					Sha256Hex: "abcd",
+				},
+			},
+			digests: map[api.DigestAlgorithm]string{api.DigestSha256: "0000"},
+			wantErr: ErrDownloadFailed,
+		},
+		{
+			name: "additional digests",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{
+					Success:   true,
+					Sha256Hex: "abcd",
+					Digests: map[api.DigestAlgorithm]string{
+						api.DigestSha256: "abcd",
+						api.DigestSha512: "1234",
+						api.DigestBlake3: "5678",
+					},
+				},
+			},
+			digests: map[api.DigestAlgorithm]string{
+				api.DigestSha256: "abcd",
+				api.DigestBlake3: "5678",
+			},
+		},
+		{
+			name: "additional digest mismatch",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{
+					Success:   true,
+					Sha256Hex: "abcd",
+					Digests: map[api.DigestAlgorithm]string{
+						api.DigestSha512: "1234",
+					},
+				},
+			},
+			digests: map[api.DigestAlgorithm]string{
+				api.DigestSha256: "abcd",
+				api.DigestSha512: "0000",
+			},
+			wantErr: ErrDownloadFailed,
+		},
+		{
+			name: "additional digest missing",
+			status: api.DownloadStatus{
+				Finished: &api.DownloadStatusFinished{
+					Success:   true,
+					Sha256Hex: "abcd",
+				},
+			},
+			digests: map[api.DigestAlgorithm]string{
+				api.DigestSha256: "abcd",
+				api.DigestBlake3: "5678",
+			},
+			wantErr: ErrDownloadFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyDownload(&tc.status, tc.digests)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256([]byte("archive"))
+	signature := ed25519.Sign(privateKey, digest[:])
+
+	for _, tc := range []struct {
+		name          string
+		verifyKey     ed25519.PublicKey
+		signatureHex  string
+		wantErr       error
+		wantSignature bool
+	}{
+		{name: "unsigned"},
+		{
+			name:      "unsigned with key",
+			verifyKey: publicKey,
+			wantErr:   ErrSignature,
+		},
+		{
+			name:          "signed",
+			signatureHex:  hex.EncodeToString(signature),
+			wantSignature: true,
+		},
+		{
+			name:          "verified",
+			verifyKey:     publicKey,
+			signatureHex:  hex.EncodeToString(signature),
+			wantSignature: true,
+		},
+		{
+			name:         "wrong key",
+			verifyKey:    otherKey,
+			signatureHex: hex.EncodeToString(signature),
+			wantErr:      ErrSignature,
+		},
+		{
+			name:         "malformed",
+			signatureHex: "xyz",
+			wantErr:      ErrSignature,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer testutils.LogOutput(t, io.Discard)()
+
+			archivePath := filepath.Join(t.TempDir(), "archive.tar")
+
+			d := Downloader{
+				verifyKey: tc.verifyKey,
+			}
+
+			err := d.checkSignature(&api.DownloadStatusFinished{
+				SignatureHex: tc.signatureHex,
+			}, digest[:], archivePath)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			got, err := os.ReadFile(archivePath + SignatureFileExtension)
+
+			if tc.wantSignature {
+				if err != nil {
+					t.Errorf("Reading signature failed: %v", err)
+				} else if diff := cmp.Diff(signature, got); diff != "" {
+					t.Errorf("Signature diff (-want +got):\n%s", diff)
+				}
+			} else if !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Signature file exists or can't be read: %v", err)
+			}
+		})
+	}
+}
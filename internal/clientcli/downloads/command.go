@@ -0,0 +1,108 @@
+package downloads
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/subcommands"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+)
+
+type ClientInterface interface {
+	ListDownloads(context.Context, api.ListDownloadsOptions) (*api.ListDownloadsResult, error)
+}
+
+type Command struct {
+	json bool
+}
+
+func (*Command) Name() string {
+	return "downloads"
+}
+
+func (*Command) Synopsis() string {
+	return `List downloads tracked by the server.`
+}
+
+func (c *Command) Usage() string {
+	return ``
+}
+
+func (c *Command) SetFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.json, "json", false,
+		"Print download information as JSON instead of a table.")
+}
+
+func writeTable(w io.Writer, downloads []api.DownloadStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tSNAPSHOT\tFORMAT\tSTARTED\tFINISHED\tSTATE\tPROGRESS\tCLIENT\tUSER AGENT")
+
+	for _, i := range downloads {
+		started := "-"
+
+		if !i.StartedAt.IsZero() {
+			started = i.StartedAt.UTC().Format(time.RFC3339)
+		}
+
+		finished := "-"
+
+		if f := i.Finished; f != nil && !f.FinishedAt.IsZero() {
+			finished = f.FinishedAt.UTC().Format(time.RFC3339)
+		}
+
+		progress := "-"
+
+		if fraction, ok := i.Progress.Fraction(); ok {
+			progress = fmt.Sprintf("%.1f%%", 100*fraction)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i.ID, i.SnapshotName, i.Format, started, finished, i.State(), progress,
+			i.ClientAddress, i.UserAgent)
+	}
+
+	return tw.Flush()
+}
+
+func (c *Command) execute(ctx context.Context, cl ClientInterface, w io.Writer) error {
+	result, err := cl.ListDownloads(ctx, api.ListDownloadsOptions{})
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(result)
+	}
+
+	return writeTable(w, result.Downloads)
+}
+
+func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	r := args[0].(*clientcli.Runtime)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	if err := r.WithClient(func(cl api.Interface) error {
+		return c.execute(ctx, cl, os.Stdout)
+	}); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,137 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+)
+
+var errTest = errors.New("test error")
+
+type fakeClient struct {
+	result api.ListDownloadsResult
+	err    error
+}
+
+func (c *fakeClient) ListDownloads(context.Context, api.ListDownloadsOptions) (*api.ListDownloadsResult, error) {
+	return &c.result, c.err
+}
+
+func TestCommand(t *testing.T) {
+	downloads := []api.DownloadStatus{
+		{
+			ID:            "20221109202035_1a3f8c9d2",
+			SnapshotName:  "20221109T202035Z-355a5b4970d5a906",
+			Format:        api.ArchiveTarZstd,
+			StartedAt:     time.Date(2022, 11, 9, 20, 20, 35, 0, time.UTC),
+			ClientAddress: "192.0.2.1:1234",
+			UserAgent:     "test/1.0",
+			Progress: &api.DownloadProgress{
+				BytesRead:  250,
+				BytesTotal: 1000,
+			},
+		},
+		{
+			ID:           "20221109203000_2b4e",
+			SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+			Format:       api.ArchiveTar,
+			StartedAt:    time.Date(2022, 11, 9, 20, 30, 0, 0, time.UTC),
+			Finished: &api.DownloadStatusFinished{
+				Success:    true,
+				FinishedAt: time.Date(2022, 11, 9, 20, 31, 15, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		client  *fakeClient
+		wantErr error
+		want    string
+	}{
+		{
+			name:   "empty",
+			client: &fakeClient{},
+			want:   "ID  SNAPSHOT  FORMAT  STARTED  FINISHED  STATE  PROGRESS  CLIENT  USER AGENT\n",
+		},
+		{
+			name: "table",
+			client: &fakeClient{
+				result: api.ListDownloadsResult{
+					Downloads: downloads,
+				},
+			},
+			want: strings.Join([]string{
+				"ID                        SNAPSHOT                           FORMAT  STARTED               FINISHED              STATE        PROGRESS  CLIENT          USER AGENT",
+				"20221109202035_1a3f8c9d2  20221109T202035Z-355a5b4970d5a906  tzst    2022-11-09T20:20:35Z  -                     in progress  25.0%     192.0.2.1:1234  test/1.0",
+				"20221109203000_2b4e       20221109T202035Z-355a5b4970d5a906  tar     2022-11-09T20:30:00Z  2022-11-09T20:31:15Z  finished     -                         ",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "json",
+			args: []string{"-json"},
+			client: &fakeClient{
+				result: api.ListDownloadsResult{
+					Downloads: downloads[1:],
+				},
+			},
+			want: `{
+  "downloads": [
+    {
+      "id": "20221109203000_2b4e",
+      "snapshot_name": "20221109T202035Z-355a5b4970d5a906",
+      "format": "tar",
+      "started_at": "2022-11-09T20:30:00Z",
+      "finished": {
+        "success": true,
+        "error_text": null,
+        "sha256_hex": "",
+        "finished_at": "2022-11-09T20:31:15Z"
+      }
+    }
+  ]
+}
+`,
+		},
+		{
+			name: "error",
+			client: &fakeClient{
+				err: errTest,
+			},
+			wantErr: errTest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+
+			var c Command
+
+			c.SetFlags(fs)
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Errorf("Flag parsing failed: %v", err)
+			}
+
+			var buf strings.Builder
+
+			err := c.execute(context.Background(), tc.client, &buf)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("Output diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -41,6 +41,57 @@ func MustGetenvBool(key string, fallback bool) bool {
 	return successOrDie(GetenvBool(key, fallback))
 }
 
+func GetenvInt(key string, fallback int) (int, error) {
+	if raw := os.Getenv(key); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return 0, fmt.Errorf("parsing %s environment variable: %w", key, err)
+		}
+
+		return parsed, nil
+	}
+
+	return fallback, nil
+}
+
+func MustGetenvInt(key string, fallback int) int {
+	return successOrDie(GetenvInt(key, fallback))
+}
+
+func GetenvUint64(key string, fallback uint64) (uint64, error) {
+	if raw := os.Getenv(key); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing %s environment variable: %w", key, err)
+		}
+
+		return parsed, nil
+	}
+
+	return fallback, nil
+}
+
+func MustGetenvUint64(key string, fallback uint64) uint64 {
+	return successOrDie(GetenvUint64(key, fallback))
+}
+
+func GetenvFloat64(key string, fallback float64) (float64, error) {
+	if raw := os.Getenv(key); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing %s environment variable: %w", key, err)
+		}
+
+		return parsed, nil
+	}
+
+	return fallback, nil
+}
+
+func MustGetenvFloat64(key string, fallback float64) float64 {
+	return successOrDie(GetenvFloat64(key, fallback))
+}
+
 func GetenvDuration(key string, fallback time.Duration) (time.Duration, error) {
 	if raw := os.Getenv(key); raw != "" {
 		parsed, err := time.ParseDuration(raw)
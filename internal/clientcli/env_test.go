@@ -67,6 +67,150 @@ func TestGetenvBool(t *testing.T) {
 	}
 }
 
+func TestGetenvInt(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		fallback int
+		want     int
+		wantErr  error
+	}{
+		{name: "unset"},
+		{
+			name:  "empty",
+			value: ref.Ref(""),
+		},
+		{
+			name:  "positive",
+			value: ref.Ref("42"),
+			want:  42,
+		},
+		{
+			name:  "negative",
+			value: ref.Ref("-3"),
+			want:  -3,
+		},
+		{
+			name:     "fallback",
+			fallback: 7,
+			want:     7,
+		},
+		{
+			name:    "error",
+			value:   ref.Ref("nope"),
+			wantErr: strconv.ErrSyntax,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Unsetenv(envVarName)
+
+			if tc.value != nil {
+				os.Setenv(envVarName, *tc.value)
+			}
+
+			got, err := GetenvInt(envVarName, tc.fallback)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GetenvInt diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetenvUint64(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		fallback uint64
+		want     uint64
+		wantErr  error
+	}{
+		{name: "unset"},
+		{
+			name:  "large",
+			value: ref.Ref("18446744073709551615"),
+			want:  18446744073709551615,
+		},
+		{
+			name:     "fallback",
+			fallback: 1024,
+			want:     1024,
+		},
+		{
+			name:    "negative",
+			value:   ref.Ref("-1"),
+			wantErr: strconv.ErrSyntax,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Unsetenv(envVarName)
+
+			if tc.value != nil {
+				os.Setenv(envVarName, *tc.value)
+			}
+
+			got, err := GetenvUint64(envVarName, tc.fallback)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GetenvUint64 diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetenvFloat64(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		fallback float64
+		want     float64
+		wantErr  error
+	}{
+		{name: "unset"},
+		{
+			name:  "fraction",
+			value: ref.Ref("12.5"),
+			want:  12.5,
+		},
+		{
+			name:     "fallback",
+			fallback: 3,
+			want:     3,
+		},
+		{
+			name:    "error",
+			value:   ref.Ref("nope"),
+			wantErr: strconv.ErrSyntax,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Unsetenv(envVarName)
+
+			if tc.value != nil {
+				os.Setenv(envVarName, *tc.value)
+			}
+
+			got, err := GetenvFloat64(envVarName, tc.fallback)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GetenvFloat64 diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestGetenvDuration(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
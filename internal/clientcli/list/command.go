@@ -0,0 +1,100 @@
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/subcommands"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+)
+
+type ClientInterface interface {
+	ListSnapshots(context.Context, api.ListSnapshotsOptions) (*api.ListSnapshotsResult, error)
+}
+
+type Command struct {
+	json bool
+}
+
+func (*Command) Name() string {
+	return "list"
+}
+
+func (*Command) Synopsis() string {
+	return `List snapshots.`
+}
+
+func (c *Command) Usage() string {
+	return ``
+}
+
+func (c *Command) SetFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.json, "json", false,
+		"Print snapshot information as JSON instead of a table.")
+}
+
+func writeTable(w io.Writer, snapshots []api.SnapshotInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "NAME\tCREATED\tSIZE\tBLOCKS\tPINNED")
+
+	for _, i := range snapshots {
+		created := "-"
+
+		if !i.CreatedAt.IsZero() {
+			created = i.CreatedAt.UTC().Format(time.RFC3339)
+		}
+
+		pinned := "no"
+
+		if i.Pinned {
+			pinned = "yes"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", i.Name, created, i.TotalSize, i.BlockCount, pinned)
+	}
+
+	return tw.Flush()
+}
+
+func (c *Command) execute(ctx context.Context, cl ClientInterface, w io.Writer) error {
+	result, err := cl.ListSnapshots(ctx, api.ListSnapshotsOptions{})
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(result)
+	}
+
+	return writeTable(w, result.Snapshots)
+}
+
+func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	r := args[0].(*clientcli.Runtime)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	if err := r.WithClient(func(cl api.Interface) error {
+		return c.execute(ctx, cl, os.Stdout)
+	}); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,120 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+)
+
+var errTest = errors.New("test error")
+
+type fakeClient struct {
+	result api.ListSnapshotsResult
+	err    error
+}
+
+func (c *fakeClient) ListSnapshots(context.Context, api.ListSnapshotsOptions) (*api.ListSnapshotsResult, error) {
+	return &c.result, c.err
+}
+
+func TestCommand(t *testing.T) {
+	snapshots := []api.SnapshotInfo{
+		{
+			Name:       "20221109T202035Z-355a5b4970d5a906",
+			CreatedAt:  time.Date(2022, 11, 9, 20, 20, 35, 0, time.UTC),
+			TotalSize:  123456,
+			BlockCount: 3,
+			Pinned:     true,
+		},
+		{
+			Name: "x-y",
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		client  *fakeClient
+		wantErr error
+		want    string
+	}{
+		{
+			name:   "empty",
+			client: &fakeClient{},
+			want:   "NAME  CREATED  SIZE  BLOCKS  PINNED\n",
+		},
+		{
+			name: "table",
+			client: &fakeClient{
+				result: api.ListSnapshotsResult{
+					Snapshots: snapshots,
+				},
+			},
+			want: strings.Join([]string{
+				"NAME                               CREATED               SIZE    BLOCKS  PINNED",
+				"20221109T202035Z-355a5b4970d5a906  2022-11-09T20:20:35Z  123456  3       yes",
+				"x-y                                -                     0       0       no",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "json",
+			args: []string{"-json"},
+			client: &fakeClient{
+				result: api.ListSnapshotsResult{
+					Snapshots: snapshots[:1],
+				},
+			},
+			want: `{
+  "snapshots": [
+    {
+      "name": "20221109T202035Z-355a5b4970d5a906",
+      "created_at": "2022-11-09T20:20:35Z",
+      "total_size": 123456,
+      "block_count": 3,
+      "pinned": true
+    }
+  ]
+}
+`,
+		},
+		{
+			name: "error",
+			client: &fakeClient{
+				err: errTest,
+			},
+			wantErr: errTest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+
+			var c Command
+
+			c.SetFlags(fs)
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Errorf("Flag parsing failed: %v", err)
+			}
+
+			var buf strings.Builder
+
+			err := c.execute(context.Background(), tc.client, &buf)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("Output diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -34,6 +34,7 @@ func NewOutputFile(path string) (*OutputFile, error) {
 			return nil, err
 		}
 
+		f.path = path
 		f.setup(fh)
 	}
 
@@ -69,14 +70,29 @@ func (f *OutputFile) Open(hint string) (io.Writer, error) {
 		return nil, err
 	}
 
+	f.path = path
 	f.setup(fh)
 
 	return f.w, nil
 }
 
+// IsStdout returns whether the output is written to standard output.
+func (f *OutputFile) IsStdout() bool {
+	return f.w == os.Stdout
+}
+
+// Name returns the path of the output file. It's empty when writing to
+// standard output or before the file was opened.
+func (f *OutputFile) Name() string {
+	return f.path
+}
+
+// Close flushes and closes the output file. Subsequent calls do nothing.
 func (f *OutputFile) Close() error {
-	if f.close != nil {
-		return f.close()
+	if close := f.close; close != nil {
+		f.close = nil
+
+		return close()
 	}
 
 	return nil
@@ -22,30 +22,36 @@ func TestOutputFile(t *testing.T) {
 	tmpdir := t.TempDir()
 
 	for _, tc := range []struct {
-		name    string
-		path    string
-		wantErr error
-		want    io.Writer
+		name       string
+		path       string
+		wantErr    error
+		want       io.Writer
+		wantStdout bool
 
 		hint        string
 		wantOpenErr error
+		wantName    string
 	}{
 		{
-			name: "stdout",
-			path: "-",
-			want: os.Stdout,
+			name:       "stdout",
+			path:       "-",
+			want:       os.Stdout,
+			wantStdout: true,
 		},
 		{
-			name: "file",
-			path: filepath.Join(tmpdir, "output.txt"),
+			name:     "file",
+			path:     filepath.Join(tmpdir, "output.txt"),
+			wantName: filepath.Join(tmpdir, "output.txt"),
 		},
 		{
-			name: "remote name",
-			hint: "remote.txt",
+			name:     "remote name",
+			hint:     "remote.txt",
+			wantName: "remote.txt",
 		},
 		{
-			name: "remote name with path",
-			hint: "../../../insecure/../../escape.txt",
+			name:     "remote name with path",
+			hint:     "../../../insecure/../../escape.txt",
+			wantName: "escape.txt",
 		},
 		{
 			name:        "remote name exists",
@@ -66,20 +72,30 @@ func TestOutputFile(t *testing.T) {
 				t.Errorf("Want writer for path %q, got %v", tc.path, o.w)
 			}
 
+			if got := o.IsStdout(); got != tc.wantStdout {
+				t.Errorf("IsStdout() = %v, want %v", got, tc.wantStdout)
+			}
+
 			w, err := o.Open(tc.hint)
 
 			if diff := cmp.Diff(tc.wantOpenErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
 			}
 
+			if diff := cmp.Diff(tc.wantName, o.Name()); diff != "" {
+				t.Errorf("Name() diff (-want +got):\n%s", diff)
+			}
+
 			if err == nil && w != os.Stdout {
 				if _, err := io.WriteString(w, "Test content"); err != nil {
 					t.Errorf("WriteString(%v) failed: %v", w, err)
 				}
 			}
 
-			if err := o.Close(); err != nil {
-				t.Errorf("Close() failed: %v", err)
+			for range 2 {
+				if err := o.Close(); err != nil {
+					t.Errorf("Close() failed: %v", err)
+				}
 			}
 		})
 	}
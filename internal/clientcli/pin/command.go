@@ -0,0 +1,73 @@
+package pin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/google/subcommands"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+)
+
+var errNameRequired = errors.New("snapshot name is required")
+
+type ClientInterface interface {
+	PinSnapshot(context.Context, api.PinSnapshotOptions) (*api.PinSnapshotResult, error)
+}
+
+type Command struct {
+	name string
+}
+
+func (*Command) Name() string {
+	return "pin"
+}
+
+func (*Command) Synopsis() string {
+	return `Protect a snapshot from pruning.`
+}
+
+func (c *Command) Usage() string {
+	return ``
+}
+
+func (c *Command) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.name, "name", "",
+		"Name of the snapshot to protect from pruning.")
+}
+
+func (c *Command) execute(ctx context.Context, cl ClientInterface) error {
+	if c.name == "" {
+		return errNameRequired
+	}
+
+	if _, err := cl.PinSnapshot(ctx, api.PinSnapshotOptions{
+		Name: c.name,
+	}); err != nil {
+		return err
+	}
+
+	log.Printf("Snapshot %s pinned", c.name)
+
+	return nil
+}
+
+func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	r := args[0].(*clientcli.Runtime)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	if err := r.WithClient(func(cl api.Interface) error {
+		return c.execute(ctx, cl)
+	}); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
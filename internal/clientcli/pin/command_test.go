@@ -0,0 +1,86 @@
+package pin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/testutils"
+)
+
+var errTest = errors.New("test error")
+
+type fakeClient struct {
+	gotOptions api.PinSnapshotOptions
+	err        error
+}
+
+func (c *fakeClient) PinSnapshot(ctx context.Context, opts api.PinSnapshotOptions) (*api.PinSnapshotResult, error) {
+	c.gotOptions = opts
+
+	return &api.PinSnapshotResult{}, c.err
+}
+
+func TestCommand(t *testing.T) {
+	defer testutils.LogOutput(t, io.Discard)()
+
+	for _, tc := range []struct {
+		name        string
+		args        []string
+		client      *fakeClient
+		wantErr     error
+		wantOptions api.PinSnapshotOptions
+	}{
+		{
+			name:    "missing name",
+			client:  &fakeClient{},
+			wantErr: errNameRequired,
+		},
+		{
+			name:   "success",
+			args:   []string{"-name", "20221109T202035Z-355a5b4970d5a906"},
+			client: &fakeClient{},
+			wantOptions: api.PinSnapshotOptions{
+				Name: "20221109T202035Z-355a5b4970d5a906",
+			},
+		},
+		{
+			name: "error",
+			args: []string{"-name", "missing-123"},
+			client: &fakeClient{
+				err: errTest,
+			},
+			wantErr: errTest,
+			wantOptions: api.PinSnapshotOptions{
+				Name: "missing-123",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+
+			var c Command
+
+			c.SetFlags(fs)
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Errorf("Flag parsing failed: %v", err)
+			}
+
+			err := c.execute(context.Background(), tc.client)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.wantOptions, tc.client.gotOptions); diff != "" {
+				t.Errorf("Options diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
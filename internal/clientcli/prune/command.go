@@ -3,7 +3,11 @@ package prune
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"text/tabwriter"
 	"time"
 
 	"github.com/google/subcommands"
@@ -16,7 +20,18 @@ type ClientInterface interface {
 }
 
 type Command struct {
-	keepWithin time.Duration
+	keepWithin  time.Duration
+	keepLast    int
+	keepHourly  int
+	keepDaily   int
+	keepWeekly  int
+	keepMonthly int
+
+	minFreeBytes   uint64
+	minFreePercent float64
+	minKeep        int
+
+	dryRun bool
 }
 
 func (*Command) Name() string {
@@ -34,16 +49,91 @@ func (c *Command) Usage() string {
 func (c *Command) SetFlags(fs *flag.FlagSet) {
 	fs.DurationVar(&c.keepWithin, "keep_within", time.Hour,
 		"Keep all snapshots within this time interval.")
+	fs.IntVar(&c.keepLast, "keep_last", 0,
+		"Keep this number of most recent snapshots.")
+	fs.IntVar(&c.keepHourly, "keep_hourly", 0,
+		"Keep the most recent snapshot for this number of hours.")
+	fs.IntVar(&c.keepDaily, "keep_daily", 0,
+		"Keep the most recent snapshot for this number of days.")
+	fs.IntVar(&c.keepWeekly, "keep_weekly", 0,
+		"Keep the most recent snapshot for this number of ISO weeks.")
+	fs.IntVar(&c.keepMonthly, "keep_monthly", 0,
+		"Keep the most recent snapshot for this number of months.")
+	fs.Uint64Var(&c.minFreeBytes, "min_free_bytes", 0,
+		"Remove the oldest remaining snapshots until the snapshot filesystem has at least this many bytes available.")
+	fs.Float64Var(&c.minFreePercent, "min_free_percent", 0,
+		"Remove the oldest remaining snapshots until the snapshot filesystem has at least this percentage of its capacity available.")
+	fs.IntVar(&c.minKeep, "min_keep", 1,
+		"Minimum number of snapshots to keep when removing snapshots to free space.")
+	fs.BoolVar(&c.dryRun, "dry_run", false,
+		"Report which snapshots would be removed without deleting them.")
 }
 
-func (c *Command) execute(ctx context.Context, cl ClientInterface) error {
-	_, err := cl.Prune(ctx, api.PruneOptions{
-		KeepWithin: c.keepWithin,
-	})
+func writeResult(w io.Writer, result *api.PruneResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	removedStatus := "removed"
+
+	if result.DryRun {
+		removedStatus = "would remove"
+	}
+
+	summary := fmt.Sprintf("Removed %d snapshot(s), reclaimed %d bytes in %v.",
+		len(result.Removed), result.ReclaimedBytes, result.Duration)
+
+	if result.DryRun {
+		summary = fmt.Sprintf("Dry run: would remove %d snapshot(s), reclaiming %d bytes.",
+			len(result.Removed), result.ReclaimedBytes)
+	}
+
+	fmt.Fprintln(tw, "STATUS\tNAME\tREASON")
+
+	for _, i := range []struct {
+		status    string
+		snapshots []api.PruneSnapshot
+	}{
+		{removedStatus, result.Removed},
+		{"in use", result.InUse},
+		{"pinned", result.Pinned},
+		{"kept", result.Kept},
+		{"invalid", result.Invalid},
+	} {
+		for _, s := range i.snapshots {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", i.status, s.Name, s.Reason)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(w, summary)
 
 	return err
 }
 
+func (c *Command) execute(ctx context.Context, cl ClientInterface, w io.Writer) error {
+	result, err := cl.Prune(ctx, api.PruneOptions{
+		KeepWithin:  c.keepWithin,
+		KeepLast:    c.keepLast,
+		KeepHourly:  c.keepHourly,
+		KeepDaily:   c.keepDaily,
+		KeepWeekly:  c.keepWeekly,
+		KeepMonthly: c.keepMonthly,
+
+		MinFreeBytes:   c.minFreeBytes,
+		MinFreePercent: c.minFreePercent,
+		MinKeep:        c.minKeep,
+
+		DryRun: c.dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeResult(w, result)
+}
+
 func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
 	r := args[0].(*clientcli.Runtime)
 
@@ -53,7 +143,7 @@ func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) su
 	}
 
 	if err := r.WithClient(func(cl api.Interface) error {
-		return c.execute(ctx, cl)
+		return c.execute(ctx, cl, os.Stdout)
 	}); err != nil {
 		log.Printf("Error: %v", err)
 		return subcommands.ExitFailure
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -16,22 +18,127 @@ var errTest = errors.New("test error")
 type fakeClient struct {
 	pruneResult api.PruneResult
 	pruneError  error
+
+	gotOptions api.PruneOptions
 }
 
-func (c *fakeClient) Prune(context.Context, api.PruneOptions) (*api.PruneResult, error) {
+func (c *fakeClient) Prune(ctx context.Context, opts api.PruneOptions) (*api.PruneResult, error) {
+	c.gotOptions = opts
+
 	return &c.pruneResult, c.pruneError
 }
 
 func TestCommand(t *testing.T) {
 	for _, tc := range []struct {
-		name    string
-		args    []string
-		client  *fakeClient
-		wantErr error
+		name        string
+		args        []string
+		client      *fakeClient
+		wantErr     error
+		wantOptions api.PruneOptions
+		wantOutput  string
 	}{
 		{
 			name:   "success",
 			client: &fakeClient{},
+			wantOptions: api.PruneOptions{
+				KeepWithin: time.Hour,
+				MinKeep:    1,
+			},
+			wantOutput: "STATUS  NAME  REASON\nRemoved 0 snapshot(s), reclaimed 0 bytes in 0s.\n",
+		},
+		{
+			name: "retention rules",
+			args: []string{
+				"-keep_within", "0",
+				"-keep_last", "3",
+				"-keep_hourly", "2",
+				"-keep_daily", "7",
+				"-keep_weekly", "4",
+				"-keep_monthly", "6",
+			},
+			client: &fakeClient{},
+			wantOptions: api.PruneOptions{
+				KeepLast:    3,
+				KeepHourly:  2,
+				KeepDaily:   7,
+				KeepWeekly:  4,
+				KeepMonthly: 6,
+				MinKeep:     1,
+			},
+			wantOutput: "STATUS  NAME  REASON\nRemoved 0 snapshot(s), reclaimed 0 bytes in 0s.\n",
+		},
+		{
+			name: "free space",
+			args: []string{
+				"-min_free_bytes", "1073741824",
+				"-min_free_percent", "10",
+				"-min_keep", "3",
+			},
+			client: &fakeClient{},
+			wantOptions: api.PruneOptions{
+				KeepWithin:     time.Hour,
+				MinFreeBytes:   1 << 30,
+				MinFreePercent: 10,
+				MinKeep:        3,
+			},
+			wantOutput: "STATUS  NAME  REASON\nRemoved 0 snapshot(s), reclaimed 0 bytes in 0s.\n",
+		},
+		{
+			name: "removed",
+			client: &fakeClient{
+				pruneResult: api.PruneResult{
+					Removed: []api.PruneSnapshot{
+						{Name: "20221109T202035Z-355a5b4970d5a906", Reason: "not kept by any rule"},
+					},
+					ReclaimedBytes: 4096,
+					Duration:       1500 * time.Millisecond,
+				},
+			},
+			wantOptions: api.PruneOptions{
+				KeepWithin: time.Hour,
+				MinKeep:    1,
+			},
+			wantOutput: strings.Join([]string{
+				"STATUS   NAME                               REASON",
+				"removed  20221109T202035Z-355a5b4970d5a906  not kept by any rule",
+				"Removed 1 snapshot(s), reclaimed 4096 bytes in 1.5s.",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "dry run",
+			args: []string{"-dry_run"},
+			client: &fakeClient{
+				pruneResult: api.PruneResult{
+					DryRun: true,
+					Removed: []api.PruneSnapshot{
+						{Name: "20221109T202035Z-355a5b4970d5a906", Reason: "not kept by any rule"},
+					},
+					Kept: []api.PruneSnapshot{
+						{Name: "20221110T101010Z-0a1b2c3d4e5f6789", Reason: "within 1h0m0s"},
+					},
+					InUse: []api.PruneSnapshot{
+						{Name: "20221109T000000Z-busy", Reason: "snapshot in use"},
+					},
+					Invalid: []api.PruneSnapshot{
+						{Name: "bad", Reason: "invalid snapshot name"},
+					},
+				},
+			},
+			wantOptions: api.PruneOptions{
+				KeepWithin: time.Hour,
+				MinKeep:    1,
+				DryRun:     true,
+			},
+			wantOutput: strings.Join([]string{
+				"STATUS        NAME                               REASON",
+				"would remove  20221109T202035Z-355a5b4970d5a906  not kept by any rule",
+				"in use        20221109T000000Z-busy              snapshot in use",
+				"kept          20221110T101010Z-0a1b2c3d4e5f6789  within 1h0m0s",
+				"invalid       bad                                invalid snapshot name",
+				"Dry run: would remove 1 snapshot(s), reclaiming 0 bytes.",
+				"",
+			}, "\n"),
 		},
 		{
 			name: "error",
@@ -39,6 +146,10 @@ func TestCommand(t *testing.T) {
 				pruneError: errTest,
 			},
 			wantErr: errTest,
+			wantOptions: api.PruneOptions{
+				KeepWithin: time.Hour,
+				MinKeep:    1,
+			},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
@@ -52,11 +163,21 @@ func TestCommand(t *testing.T) {
 				t.Errorf("Flag parsing failed: %v", err)
 			}
 
-			err := c.execute(context.Background(), tc.client)
+			var buf strings.Builder
+
+			err := c.execute(context.Background(), tc.client, &buf)
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
 			}
+
+			if diff := cmp.Diff(tc.wantOptions, tc.client.gotOptions); diff != "" {
+				t.Errorf("Options diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.wantOutput, buf.String()); diff != "" {
+				t.Errorf("Output diff (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
@@ -3,6 +3,9 @@ package clientcli
 import "github.com/hansmi/prombackup/api"
 
 type Runtime struct {
+	// Server endpoint URL as given on the command line.
+	ServerURL string
+
 	NewClient func() (api.Interface, error)
 }
 
@@ -0,0 +1,128 @@
+package clientcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+// ChecksumFileExtension is appended to the archive filename to store its
+// SHA256 checksum in the format used by sha256sum.
+const ChecksumFileExtension = ".sha256"
+
+// MetadataFileExtension is appended to the archive filename to store
+// information about the download as JSON.
+const MetadataFileExtension = ".json"
+
+// Metadata describes a downloaded archive.
+type Metadata struct {
+	// Snapshot name.
+	SnapshotName string `json:"snapshot_name"`
+
+	// Unique download ID assigned by the server.
+	DownloadID string `json:"download_id"`
+
+	// Server endpoint URL without credentials.
+	Server string `json:"server"`
+
+	// Archive format.
+	Format api.ArchiveFormat `json:"format"`
+
+	// Whether the archive is encrypted.
+	Encrypted bool `json:"encrypted,omitempty"`
+
+	// Archive filename, relative to the metadata file.
+	Filename string `json:"filename"`
+
+	// Time at which the server started and finished generating the archive.
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Time at which the download was verified by the client.
+	DownloadedAt time.Time `json:"downloaded_at"`
+
+	// Hex-encoded SHA256 checksum of the archive.
+	Sha256Hex string `json:"sha256_hex"`
+
+	// Hex-encoded digests of the archive, keyed by algorithm.
+	Digests map[api.DigestAlgorithm]string `json:"digests,omitempty"`
+
+	// Hex-encoded Ed25519 signature over the SHA256 digest.
+	SignatureHex string `json:"signature_hex,omitempty"`
+}
+
+// stripCredentials removes the user information from a server URL. Metadata
+// files are usually stored alongside backups and must not leak passwords.
+// Unparseable URLs are dropped entirely.
+func stripCredentials(server string) string {
+	u, err := url.Parse(server)
+	if err != nil {
+		return ""
+	}
+
+	u.User = nil
+
+	return u.String()
+}
+
+// NewMetadata builds the metadata of a successful download.
+func NewMetadata(status *api.DownloadStatus, server, archivePath string) Metadata {
+	m := Metadata{
+		SnapshotName: status.SnapshotName,
+		DownloadID:   status.ID,
+		Server:       stripCredentials(server),
+		Format:       status.Format,
+		Encrypted:    status.Encrypted,
+		Filename:     filepath.Base(archivePath),
+		StartedAt:    status.StartedAt,
+		DownloadedAt: time.Now().UTC(),
+	}
+
+	if sf := status.Finished; sf != nil {
+		m.FinishedAt = sf.FinishedAt
+		m.Sha256Hex = sf.Sha256Hex
+		m.Digests = sf.Digests
+		m.SignatureHex = sf.SignatureHex
+	}
+
+	return m
+}
+
+// WriteChecksumFile writes the checksum of an archive next to it. The file
+// can be checked using "sha256sum --check".
+func WriteChecksumFile(archivePath, sha256Hex string) error {
+	path := archivePath + ChecksumFileExtension
+	content := fmt.Sprintf("%s  %s\n", sha256Hex, filepath.Base(archivePath))
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return err
+	}
+
+	log.Printf("Wrote checksum to %s", path)
+
+	return nil
+}
+
+// WriteMetadataFile writes download metadata next to the archive.
+func WriteMetadataFile(archivePath string, m Metadata) error {
+	path := archivePath + MetadataFileExtension
+
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	log.Printf("Wrote metadata to %s", path)
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/subcommands"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+)
+
+var errIDRequired = errors.New("download ID is required")
+var errNotFinished = errors.New("download not finished")
+var errInvalidInterval = errors.New("interval must be positive")
+
+type ClientInterface interface {
+	DownloadStatus(context.Context, api.DownloadStatusOptions) (*api.DownloadStatus, error)
+}
+
+type Command struct {
+	id       string
+	wait     bool
+	interval time.Duration
+}
+
+func (*Command) Name() string {
+	return "status"
+}
+
+func (*Command) Synopsis() string {
+	return `Show the status of a download.`
+}
+
+func (c *Command) Usage() string {
+	return ``
+}
+
+func (c *Command) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.id, "id", "",
+		"Download ID as reported by the server.")
+	fs.BoolVar(&c.wait, "wait", false,
+		"Wait for the download to finish.")
+	fs.DurationVar(&c.interval, "interval", 5*time.Second,
+		"How often to request the status while waiting.")
+}
+
+func logProgress(status *api.DownloadStatus) {
+	p := status.Progress
+	if p == nil {
+		log.Printf("Download %s has not started yet", status.ID)
+		return
+	}
+
+	msg := fmt.Sprintf("Download %s: %d/%d files, %d/%d bytes read, %d bytes written",
+		status.ID, p.FilesDone, p.FilesTotal, p.BytesRead, p.BytesTotal, p.BytesWritten)
+
+	if remaining, ok := p.Remaining(time.Now()); ok {
+		msg += fmt.Sprintf(", %v remaining", remaining.Round(time.Second))
+	}
+
+	log.Print(msg)
+}
+
+func writeStatus(w io.Writer, status *api.DownloadStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintf(tw, "ID:\t%s\n", status.ID)
+	fmt.Fprintf(tw, "Snapshot:\t%s\n", status.SnapshotName)
+
+	fmt.Fprintf(tw, "Status:\t%s\n", status.State())
+
+	if sf := status.Finished; sf != nil {
+		if sf.ErrorText != nil {
+			fmt.Fprintf(tw, "Error:\t%s\n", *sf.ErrorText)
+		}
+
+		if sf.Sha256Hex != "" {
+			fmt.Fprintf(tw, "SHA256:\t%s\n", sf.Sha256Hex)
+		}
+
+		for _, a := range api.DigestAlgorithmAll {
+			if value := sf.Digests[a]; a != api.DigestSha256 && value != "" {
+				fmt.Fprintf(tw, "%s:\t%s\n", strings.ToUpper(a.Name()), value)
+			}
+		}
+	}
+
+	return tw.Flush()
+}
+
+func (c *Command) validate() error {
+	if c.id == "" {
+		return errIDRequired
+	}
+
+	if c.wait && c.interval <= 0 {
+		return fmt.Errorf("%w: %v", errInvalidInterval, c.interval)
+	}
+
+	return nil
+}
+
+func (c *Command) execute(ctx context.Context, cl ClientInterface, w io.Writer) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	var ticker *time.Ticker
+
+	for {
+		status, err := cl.DownloadStatus(ctx, api.DownloadStatusOptions{
+			ID: c.id,
+		})
+		if err != nil {
+			return err
+		}
+
+		if status.Finished == nil && c.wait {
+			logProgress(status)
+
+			if ticker == nil {
+				ticker = time.NewTicker(c.interval)
+				defer ticker.Stop()
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+
+			continue
+		}
+
+		if err := writeStatus(w, status); err != nil {
+			return err
+		}
+
+		if sf := status.Finished; sf == nil {
+			return errNotFinished
+		} else if !sf.Success {
+			return clientcli.ErrDownloadFailed
+		}
+
+		return nil
+	}
+}
+
+func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	r := args[0].(*clientcli.Runtime)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	if err := c.validate(); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitUsageError
+	}
+
+	if err := r.WithClient(func(cl api.Interface) error {
+		return c.execute(ctx, cl, os.Stdout)
+	}); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
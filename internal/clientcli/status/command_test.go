@@ -0,0 +1,214 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/clientcli"
+	"github.com/hansmi/prombackup/internal/ref"
+	"github.com/hansmi/prombackup/internal/testutils"
+)
+
+var errTest = errors.New("test error")
+
+type fakeClient struct {
+	statuses []api.DownloadStatus
+	err      error
+	calls    int
+}
+
+func (c *fakeClient) DownloadStatus(ctx context.Context, opts api.DownloadStatusOptions) (*api.DownloadStatus, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	status := c.statuses[min(c.calls, len(c.statuses)-1)]
+	status.ID = opts.ID
+
+	c.calls++
+
+	return &status, nil
+}
+
+func TestCommand(t *testing.T) {
+	defer testutils.LogOutput(t, io.Discard)()
+
+	inProgress := api.DownloadStatus{
+		SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+		Progress: &api.DownloadProgress{
+			BytesRead:  10,
+			BytesTotal: 100,
+		},
+	}
+	success := api.DownloadStatus{
+		SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+		Finished: &api.DownloadStatusFinished{
+			Success:   true,
+			Sha256Hex: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+	failed := api.DownloadStatus{
+		SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+		Finished: &api.DownloadStatusFinished{
+			ErrorText: ref.Ref("disk on fire"),
+		},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		client    *fakeClient
+		wantErr   error
+		wantCalls int
+		want      string
+	}{
+		{
+			name:    "missing ID",
+			client:  &fakeClient{},
+			wantErr: errIDRequired,
+		},
+		{
+			name:    "zero interval",
+			args:    []string{"-id", "1667766556_abc", "-wait", "-interval", "0"},
+			client:  &fakeClient{},
+			wantErr: errInvalidInterval,
+		},
+		{
+			name:    "negative interval",
+			args:    []string{"-id", "1667766556_abc", "-wait", "-interval", "-1s"},
+			client:  &fakeClient{},
+			wantErr: errInvalidInterval,
+		},
+		{
+			name: "success",
+			args: []string{"-id", "1667766556_abc"},
+			client: &fakeClient{
+				statuses: []api.DownloadStatus{success},
+			},
+			wantCalls: 1,
+			want: strings.Join([]string{
+				"ID:       1667766556_abc",
+				"Snapshot: 20221109T202035Z-355a5b4970d5a906",
+				"Status:   finished",
+				"SHA256:   e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "failed",
+			args: []string{"-id", "1667766556_abc"},
+			client: &fakeClient{
+				statuses: []api.DownloadStatus{failed},
+			},
+			wantErr:   clientcli.ErrDownloadFailed,
+			wantCalls: 1,
+			want: strings.Join([]string{
+				"ID:       1667766556_abc",
+				"Snapshot: 20221109T202035Z-355a5b4970d5a906",
+				"Status:   failed",
+				"Error:    disk on fire",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "not finished",
+			args: []string{"-id", "1667766556_abc"},
+			client: &fakeClient{
+				statuses: []api.DownloadStatus{inProgress},
+			},
+			wantErr:   errNotFinished,
+			wantCalls: 1,
+			want: strings.Join([]string{
+				"ID:       1667766556_abc",
+				"Snapshot: 20221109T202035Z-355a5b4970d5a906",
+				"Status:   in progress",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "wait",
+			args: []string{"-id", "1667766556_abc", "-wait", "-interval", "1ms"},
+			client: &fakeClient{
+				statuses: []api.DownloadStatus{{}, inProgress, inProgress, success},
+			},
+			wantCalls: 4,
+			want: strings.Join([]string{
+				"ID:       1667766556_abc",
+				"Snapshot: 20221109T202035Z-355a5b4970d5a906",
+				"Status:   finished",
+				"SHA256:   e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "additional digests",
+			args: []string{"-id", "1667766556_abc"},
+			client: &fakeClient{
+				statuses: []api.DownloadStatus{{
+					ID:           "1667766556_abc",
+					SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+					Finished: &api.DownloadStatusFinished{
+						Success:   true,
+						Sha256Hex: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+						Digests: map[api.DigestAlgorithm]string{
+							api.DigestSha256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+							api.DigestBlake3: "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+						},
+					},
+				}},
+			},
+			wantCalls: 1,
+			want: strings.Join([]string{
+				"ID:       1667766556_abc",
+				"Snapshot: 20221109T202035Z-355a5b4970d5a906",
+				"Status:   finished",
+				"SHA256:   e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				"BLAKE3:   af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+				"",
+			}, "\n"),
+		},
+		{
+			name: "error",
+			args: []string{"-id", "1667766556_abc"},
+			client: &fakeClient{
+				err: errTest,
+			},
+			wantErr: errTest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+
+			var c Command
+
+			c.SetFlags(fs)
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Errorf("Flag parsing failed: %v", err)
+			}
+
+			var buf strings.Builder
+
+			err := c.execute(context.Background(), tc.client, &buf)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("Output diff (-want +got):\n%s", diff)
+			}
+
+			if tc.client.calls != tc.wantCalls {
+				t.Errorf("Client called %d times, want %d", tc.client.calls, tc.wantCalls)
+			}
+		})
+	}
+}
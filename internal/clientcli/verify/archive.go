@@ -0,0 +1,216 @@
+package verify
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/hansmi/prombackup/api"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/sha256-simd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+	"go.uber.org/multierr"
+)
+
+var errEncrypted = errors.New("archive is encrypted, use the decrypt command first")
+var errUnknownFormat = errors.New("unable to detect archive format, use -format")
+
+// Offset of the magic value in tar headers.
+const tarMagicOffset = 257
+
+var formatMagic = []struct {
+	format api.ArchiveFormat
+	magic  []byte
+}{
+	{api.ArchiveTarGzip, []byte{0x1f, 0x8b}},
+	{api.ArchiveTarZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{api.ArchiveTarXz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{api.ArchiveTarLz4, []byte{0x04, 0x22, 0x4d, 0x18}},
+	{api.ArchiveTarBzip2, []byte("BZh")},
+	{api.ArchiveZip, []byte("PK\x03\x04")},
+}
+
+// detectFormat determines the archive format from the magic bytes at the
+// start of the data.
+func detectFormat(br *bufio.Reader) (api.ArchiveFormat, error) {
+	head, err := br.Peek(tarMagicOffset + 8)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	if bytes.HasPrefix(head, []byte("age-encryption.org/")) ||
+		bytes.HasPrefix(head, []byte("-----BEGIN AGE ENCRYPTED FILE-----")) {
+		return "", errEncrypted
+	}
+
+	for _, i := range formatMagic {
+		if bytes.HasPrefix(head, i.magic) {
+			return i.format, nil
+		}
+	}
+
+	// Both the POSIX ("ustar\x0000") and GNU ("ustar  \x00") formats
+	if len(head) > tarMagicOffset && bytes.HasPrefix(head[tarMagicOffset:], []byte("ustar")) {
+		return api.ArchiveTar, nil
+	}
+
+	return "", errUnknownFormat
+}
+
+// decompress returns a reader for the uncompressed tar stream of an archive.
+func decompress(f api.ArchiveFormat, r io.Reader) (io.ReadCloser, error) {
+	switch f {
+	case api.ArchiveTar:
+		return io.NopCloser(r), nil
+
+	case api.ArchiveTarGzip:
+		return gzip.NewReader(r)
+
+	case api.ArchiveTarZstd:
+		// Synchronous decoding ensures no data is read after closing
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+
+		return dec.IOReadCloser(), nil
+
+	case api.ArchiveTarXz:
+		dec, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(dec), nil
+
+	case api.ArchiveTarLz4:
+		return io.NopCloser(lz4.NewReader(r)), nil
+
+	case api.ArchiveTarBzip2:
+		return bzip2.NewReader(r, nil)
+	}
+
+	return nil, fmt.Errorf("unsupported archive format %q", f)
+}
+
+type walkFunc func(name string, r io.Reader) error
+
+// archiveInfo describes an archive file.
+type archiveInfo struct {
+	format    api.ArchiveFormat
+	sha256Hex string
+}
+
+// walkArchive invokes fn for every regular file in the archive. The format is
+// detected from the content unless given. The SHA256 digest is computed over
+// the whole file.
+func walkArchive(path string, format api.ArchiveFormat, fn walkFunc) (_ *archiveInfo, err error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer multierr.AppendInvoke(&err, multierr.Close(fh))
+
+	h := sha256.New()
+	br := bufio.NewReaderSize(io.TeeReader(fh, h), 1024*1024)
+
+	if format == "" {
+		if format, err = detectFormat(br); err != nil {
+			return nil, err
+		}
+	}
+
+	if format == api.ArchiveZip {
+		err = walkZip(path, fn)
+	} else {
+		err = walkTar(format, br, fn)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Include trailing data in the digest
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return nil, err
+	}
+
+	return &archiveInfo{
+		format:    format,
+		sha256Hex: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+func walkTar(format api.ArchiveFormat, r io.Reader, fn walkFunc) (err error) {
+	dr, err := decompress(format, r)
+	if err != nil {
+		return err
+	}
+
+	defer multierr.AppendInvoke(&err, multierr.Close(dr))
+
+	for tr := tar.NewReader(dr); ; {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%w: malformed tar archive: %w", ErrVerifyFailed, err)
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			if err := fn(hdr.Name, tr); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Decompressors validate checksums and trailers only when reaching the
+	// end of the stream, past the end-of-archive marker.
+	if _, err := io.Copy(io.Discard, dr); err != nil {
+		return fmt.Errorf("%w: corrupt compressed data: %w", ErrVerifyFailed, err)
+	}
+
+	return nil
+}
+
+func walkZip(path string, fn walkFunc) (err error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+
+	defer multierr.AppendInvoke(&err, multierr.Close(zr))
+
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		if err := walkZipFile(f, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkZipFile(f *zip.File, fn walkFunc) (err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+
+	defer multierr.AppendInvoke(&err, multierr.Close(rc))
+
+	return fn(f.Name, rc)
+}
@@ -0,0 +1,166 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/subcommands"
+	"github.com/hansmi/prombackup/api"
+	"go.uber.org/multierr"
+)
+
+var errNoChecksum = errors.New("no checksum found")
+var errChecksumFlags = errors.New("-sha256 and -checksum_file are mutually exclusive")
+
+type Command struct {
+	format          string
+	sha256Hex       string
+	checksumPath    string
+	requireManifest bool
+}
+
+func (*Command) Name() string {
+	return "verify"
+}
+
+func (*Command) Synopsis() string {
+	return `Verify the layout and embedded manifest of a snapshot archive.`
+}
+
+func (c *Command) Usage() string {
+	return `verify [-format <format>] <archive>
+`
+}
+
+func (c *Command) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.format, "format", "",
+		fmt.Sprintf(`Archive format. One of %q. Detected from the content by default.`, api.ArchiveFormatAll))
+	fs.StringVar(&c.sha256Hex, "sha256", "",
+		"Expected hex-encoded SHA256 checksum of the archive file.")
+	fs.StringVar(&c.checksumPath, "checksum_file", "",
+		`Path to a file with the expected SHA256 checksum of the archive as written by "sha256sum" or the create command.`)
+	fs.BoolVar(&c.requireManifest, "require_manifest", false,
+		"Fail if the archive doesn't contain a manifest. Archives created by older versions don't have one.")
+}
+
+// readChecksumFile returns the first checksum from a file in the format
+// written by sha256sum.
+func readChecksumFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if fields := strings.Fields(string(content)); len(fields) > 0 {
+		return fields[0], nil
+	}
+
+	return "", fmt.Errorf("%s: %w", path, errNoChecksum)
+}
+
+// expectedChecksum returns the expected SHA256 checksum of the archive, if
+// any.
+func (c *Command) expectedChecksum() (string, error) {
+	if c.checksumPath == "" {
+		return strings.ToLower(c.sha256Hex), nil
+	}
+
+	if c.sha256Hex != "" {
+		return "", errChecksumFlags
+	}
+
+	value, err := readChecksumFile(c.checksumPath)
+
+	return strings.ToLower(value), err
+}
+
+// formatBlockTime formats a TSDB timestamp in milliseconds.
+func formatBlockTime(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
+
+func writeSummary(w io.Writer, info *archiveInfo, chk *checker) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+
+	manifest := "none"
+
+	if chk.manifest != nil {
+		manifest = "verified"
+	}
+
+	fmt.Fprintf(tw, "Format:\t%s\n", info.format)
+	fmt.Fprintf(tw, "SHA256:\t%s\n", info.sha256Hex)
+	fmt.Fprintf(tw, "Manifest:\t%s\n", manifest)
+	fmt.Fprintf(tw, "Snapshot:\t%s\n", chk.snapshotName)
+	fmt.Fprintf(tw, "Files:\t%d\n", len(chk.order))
+	fmt.Fprintf(tw, "Blocks:\t%d\n", len(chk.blocks))
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(chk.blocks) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "ULID\tMin time\tMax time\n")
+
+	for _, b := range chk.blocks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", b.ULID, formatBlockTime(b.MinTime), formatBlockTime(b.MaxTime))
+	}
+
+	return tw.Flush()
+}
+
+func (c *Command) execute(path string, w io.Writer) error {
+	wantChecksum, err := c.expectedChecksum()
+	if err != nil {
+		return err
+	}
+
+	chk := newChecker()
+
+	info, err := walkArchive(path, api.ArchiveFormat(c.format), chk.add)
+	if err != nil {
+		return err
+	}
+
+	if wantChecksum != "" && info.sha256Hex != wantChecksum {
+		multierr.AppendInto(&err, fmt.Errorf("%w: archive SHA256 mismatch (got %s, want %s)", ErrVerifyFailed, info.sha256Hex, wantChecksum))
+	}
+
+	multierr.AppendInto(&err, chk.checkManifest(c.requireManifest))
+	multierr.AppendInto(&err, chk.checkLayout())
+
+	if err != nil {
+		return err
+	}
+
+	return writeSummary(w, info, chk)
+}
+
+func (c *Command) Execute(ctx context.Context, fs *flag.FlagSet, args ...any) subcommands.ExitStatus {
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	if err := c.execute(fs.Arg(0), os.Stdout); err != nil {
+		log.Printf("Error: %v", err)
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,542 @@
+package verify
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/snapshotstream"
+)
+
+const testBlockMeta = `{"ulid": "01GHCE3ZV1VQ4M6F2XJ0BHZ4RA", "minTime": 1667952000000, "maxTime": 1667959200000, "version": 1}`
+
+var testIndex = string(append([]byte{0xba, 0xaa, 0xd7, 0x00, 2}, make([]byte, 100)...))
+var testChunks = string(append([]byte{0x85, 0xbd, 0x40, 0xdd, 1, 0, 0, 0}, bytes.Repeat([]byte("chunk data"), 1000)...))
+
+var testSnapshot = fstest.MapFS{
+	"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json": {
+		Data: []byte(testBlockMeta),
+	},
+	"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index": {
+		Data: []byte(testIndex),
+	},
+	"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/chunks/000001": {
+		Data: []byte(testChunks),
+	},
+	"wal/00000000": {
+		Data: []byte("wal"),
+	},
+}
+
+// writeSnapshotArchive generates an archive of the test snapshot.
+func writeSnapshotArchive(t *testing.T, dir string, format api.ArchiveFormat) string {
+	t.Helper()
+
+	s, err := snapshotstream.New(snapshotstream.Options{
+		Name:   "20221109T202035Z-355a5b4970d5a906",
+		Root:   testSnapshot,
+		Format: format,
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := s.WriteArchive(context.Background(), &buf); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	path := filepath.Join(dir, s.Filename)
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+type testTarEntry struct {
+	name    string
+	content string
+}
+
+func buildTar(t *testing.T, entries []testTarEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     e.name,
+			Size:     int64(len(e.content)),
+			Mode:     0o644,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// withManifest appends a manifest listing all given entries.
+func withManifest(t *testing.T, entries ...testTarEntry) []testTarEntry {
+	t.Helper()
+
+	var files []api.ManifestFile
+
+	for _, e := range entries {
+		sum := sha256.Sum256([]byte(e.content))
+
+		files = append(files, api.ManifestFile{
+			Path:      e.name,
+			Size:      int64(len(e.content)),
+			Sha256Hex: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	return append(entries, manifestEntry(t, files...))
+}
+
+func manifestEntry(t *testing.T, files ...api.ManifestFile) testTarEntry {
+	t.Helper()
+
+	content, err := json.Marshal(api.Manifest{
+		Version:      api.ManifestVersion,
+		SnapshotName: "snap",
+		Files:        files,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testTarEntry{name: "snap/" + api.ManifestName, content: string(content)}
+}
+
+func TestCommand(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	for _, format := range api.ArchiveFormatAll {
+		t.Run(format.Name(), func(t *testing.T) {
+			path := writeSnapshotArchive(t, tmpdir, format)
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sum := sha256.Sum256(content)
+
+			c := Command{
+				sha256Hex: hex.EncodeToString(sum[:]),
+			}
+
+			var buf bytes.Buffer
+
+			if err := c.execute(path, &buf); err != nil {
+				t.Errorf("execute() failed: %v", err)
+			}
+
+			wantSummary := strings.Join([]string{
+				"Format:   " + format.Name(),
+				"SHA256:   " + hex.EncodeToString(sum[:]),
+				"Manifest: verified",
+				"Snapshot: 20221109T202035Z-355a5b4970d5a906",
+				"Files:    4",
+				"Blocks:   1",
+				"",
+				"ULID                        Min time              Max time",
+				"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA  2022-11-09T00:00:00Z  2022-11-09T02:00:00Z",
+				"",
+			}, "\n")
+
+			if diff := cmp.Diff(wantSummary, buf.String()); diff != "" {
+				t.Errorf("Output diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommandCorruptTrailer(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	for _, format := range []api.ArchiveFormat{api.ArchiveTarGzip, api.ArchiveTarZstd} {
+		t.Run(format.Name(), func(t *testing.T) {
+			path := writeSnapshotArchive(t, tmpdir, format)
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Both formats end with a checksum of the uncompressed data
+			content[len(content)-1] ^= 0xff
+
+			if err := os.WriteFile(path, content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var c Command
+
+			if err := c.execute(path, io.Discard); !errors.Is(err, ErrVerifyFailed) {
+				t.Errorf("execute() returned %v, want %v", err, ErrVerifyFailed)
+			}
+		})
+	}
+}
+
+func TestCommandWithoutManifest(t *testing.T) {
+	content := buildTar(t, []testTarEntry{
+		{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/chunks/000001", content: testChunks},
+		{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: testIndex},
+		{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: testBlockMeta},
+		{name: "snap/wal/00000000", content: "wal"},
+	})
+	sum := sha256.Sum256(content)
+
+	path := filepath.Join(t.TempDir(), "archive.tar")
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var c Command
+	var buf bytes.Buffer
+
+	if err := c.execute(path, &buf); err != nil {
+		t.Errorf("execute() failed: %v", err)
+	}
+
+	wantSummary := strings.Join([]string{
+		"Format:   tar",
+		"SHA256:   " + hex.EncodeToString(sum[:]),
+		"Manifest: none",
+		"Snapshot: snap",
+		"Files:    4",
+		"Blocks:   1",
+		"",
+		"ULID                        Min time              Max time",
+		"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA  2022-11-09T00:00:00Z  2022-11-09T02:00:00Z",
+		"",
+	}, "\n")
+
+	if diff := cmp.Diff(wantSummary, buf.String()); diff != "" {
+		t.Errorf("Output diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCommandMismatch(t *testing.T) {
+	good := api.ManifestFile{
+		Path:      "snap/good",
+		Size:      4,
+		Sha256Hex: "770e607624d689265ca6c44884d0807d9b054d23c473c106c72be9de08b7376c",
+	}
+
+	valid := buildTar(t, []testTarEntry{
+		{name: "snap/good", content: "good"},
+		manifestEntry(t, good),
+	})
+
+	checksumFile := filepath.Join(t.TempDir(), "checksum.sha256")
+
+	if err := os.WriteFile(checksumFile, []byte("a3b4f6e8b2d7e5c0fbd4a21a4a1f0f3a0e5b6c7d8e9f00112233445566778899  valid.tar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		content  []byte
+		entries  []testTarEntry
+		wantErr  error
+		wantText string
+	}{
+		{
+			name:    "valid",
+			content: valid,
+		},
+		{
+			name:    "format flag",
+			args:    []string{"-format", "tar"},
+			content: valid,
+		},
+		{
+			name:    "unknown format",
+			content: []byte("garbage"),
+			wantErr: errUnknownFormat,
+		},
+		{
+			name:    "encrypted",
+			content: []byte("age-encryption.org/v1\n-> X25519 abc\n"),
+			wantErr: errEncrypted,
+		},
+		{
+			name:     "checksum",
+			args:     []string{"-sha256", "1AB2ED8B4D4A0A4F8A1B0DB1D4C5E2B9F1DCD0C1E3F6A7B8C9D0E1F2A3B4C5D6"},
+			content:  valid,
+			wantErr:  ErrVerifyFailed,
+			wantText: "archive SHA256 mismatch",
+		},
+		{
+			name:     "checksum file",
+			args:     []string{"-checksum_file", checksumFile},
+			content:  valid,
+			wantErr:  ErrVerifyFailed,
+			wantText: "want a3b4f6e8b2d7e5c0fbd4a21a4a1f0f3a0e5b6c7d8e9f00112233445566778899",
+		},
+		{
+			name:    "checksum flags",
+			args:    []string{"-sha256", "abcd", "-checksum_file", checksumFile},
+			content: valid,
+			wantErr: errChecksumFlags,
+		},
+		{
+			name:     "malformed",
+			content:  valid[:1100],
+			wantErr:  ErrVerifyFailed,
+			wantText: "malformed tar archive",
+		},
+		{
+			name:     "truncated content",
+			content:  valid[:514],
+			wantErr:  ErrVerifyFailed,
+			wantText: "snap/good: unexpected EOF",
+		},
+		{
+			name: "no manifest",
+			entries: []testTarEntry{
+				{name: "snap/good", content: "good"},
+			},
+		},
+		{
+			name: "no manifest with layout error",
+			entries: []testTarEntry{
+				{name: "snap/good", content: "good"},
+				{name: "stray", content: "stray"},
+			},
+			wantErr:  ErrVerifyFailed,
+			wantText: "stray: file outside snapshot directory",
+		},
+		{
+			name: "no manifest with checksum mismatch",
+			args: []string{"-sha256", "1ab2ed8b4d4a0a4f8a1b0db1d4c5e2b9f1dcd0c1e3f6a7b8c9d0e1f2a3b4c5d6"},
+			entries: []testTarEntry{
+				{name: "snap/good", content: "good"},
+			},
+			wantErr:  ErrVerifyFailed,
+			wantText: "archive SHA256 mismatch",
+		},
+		{
+			name: "manifest required",
+			args: []string{"-require_manifest"},
+			entries: []testTarEntry{
+				{name: "snap/good", content: "good"},
+			},
+			wantErr:  ErrVerifyFailed,
+			wantText: "doesn't contain a manifest",
+		},
+		{
+			name: "modified",
+			entries: []testTarEntry{
+				{name: "snap/good", content: "evil"},
+				manifestEntry(t, good),
+			},
+			wantErr:  ErrVerifyFailed,
+			wantText: "snap/good: SHA256 mismatch",
+		},
+		{
+			name: "truncated",
+			entries: []testTarEntry{
+				{name: "snap/good", content: "go"},
+				manifestEntry(t, good),
+			},
+			wantErr:  ErrVerifyFailed,
+			wantText: "snap/good: size mismatch",
+		},
+		{
+			name: "missing",
+			entries: []testTarEntry{
+				manifestEntry(t, good),
+			},
+			wantErr:  ErrVerifyFailed,
+			wantText: "snap/good: missing from archive",
+		},
+		{
+			name: "unlisted",
+			entries: []testTarEntry{
+				{name: "snap/good", content: "good"},
+				{name: "snap/extra", content: "extra"},
+				manifestEntry(t, good),
+			},
+			wantErr:  ErrVerifyFailed,
+			wantText: "snap/extra: not listed in manifest",
+		},
+		{
+			name: "outside snapshot directory",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/good", content: "good"},
+				testTarEntry{name: "stray", content: "stray"},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "stray: file outside snapshot directory",
+		},
+		{
+			name: "multiple snapshots",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/good", content: "good"},
+				testTarEntry{name: "other/good", content: "good"},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "multiple snapshot directories",
+		},
+		{
+			name: "valid block",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/chunks/000001", content: testChunks},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: testIndex},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: testBlockMeta},
+			),
+		},
+		{
+			name: "block without meta",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: testIndex},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA: block without meta.json",
+		},
+		{
+			name: "block without index",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: testBlockMeta},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA: block without index",
+		},
+		{
+			name: "block ID mismatch",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RB/index", content: testIndex},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RB/meta.json", content: testBlockMeta},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "doesn't match directory",
+		},
+		{
+			name: "invalid block meta",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: testIndex},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: "{"},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json:",
+		},
+		{
+			name: "invalid time range",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: testIndex},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: `{"ulid": "01GHCE3ZV1VQ4M6F2XJ0BHZ4RA", "minTime": 2000, "maxTime": 1000, "version": 1}`},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "invalid time range",
+		},
+		{
+			name: "index too small",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: testIndex[:20]},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: testBlockMeta},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "index too small (20 bytes)",
+		},
+		{
+			name: "invalid index header",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: strings.Repeat("x", 100)},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: testBlockMeta},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "invalid index header",
+		},
+		{
+			name: "chunk file too small",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/chunks/000001", content: testChunks[:4]},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: testIndex},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: testBlockMeta},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "chunk file too small (4 bytes)",
+		},
+		{
+			name: "invalid chunk file header",
+			entries: withManifest(t,
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/chunks/000001", content: "not a chunk file"},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: testIndex},
+				testTarEntry{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: testBlockMeta},
+			),
+			wantErr:  ErrVerifyFailed,
+			wantText: "invalid chunk file header",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "archive")
+			content := tc.content
+
+			if content == nil {
+				content = buildTar(t, tc.entries)
+			}
+
+			if err := os.WriteFile(path, content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+
+			var c Command
+
+			c.SetFlags(fs)
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Errorf("Flag parsing failed: %v", err)
+			}
+
+			var buf bytes.Buffer
+
+			err := c.execute(path, &buf)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), tc.wantText) {
+				t.Errorf("Error %q doesn't contain %q", err.Error(), tc.wantText)
+			}
+		})
+	}
+}
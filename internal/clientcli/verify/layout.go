@@ -0,0 +1,185 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hansmi/prombackup/api"
+	"go.uber.org/multierr"
+)
+
+// Number of bytes retained from the start of every file for validating file
+// headers.
+const fileHeadSize = 8
+
+// Maximum size of retained TSDB block metadata files.
+const blockMetaMaxSize = 1024 * 1024
+
+// TSDB index files start with a magic value and a format version. The table
+// of contents at the end consists of six 64-bit offsets and a CRC32 checksum.
+var indexMagic = []byte{0xba, 0xaa, 0xd7, 0x00}
+
+const indexMinSize = 4 + 1 + 6*8 + 4
+
+// TSDB chunk segment files start with a magic value, a format version and
+// three bytes of padding.
+var chunksMagic = []byte{0x85, 0xbd, 0x40, 0xdd}
+
+const chunksHeaderSize = 8
+
+// blockMeta contains the relevant fields of a TSDB block's "meta.json".
+type blockMeta struct {
+	ULID    string `json:"ulid"`
+	MinTime int64  `json:"minTime"`
+	MaxTime int64  `json:"maxTime"`
+	Version int    `json:"version"`
+}
+
+// splitPath splits a path into the snapshot directory, a block (or other)
+// directory and the remainder. Missing components are empty.
+func splitPath(name string) (snapshot, dir, rest string) {
+	parts := strings.SplitN(name, "/", 3)
+
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+
+	return parts[0], parts[1], parts[2]
+}
+
+// checkLayout verifies that all files are within a single snapshot directory
+// and validates the files of every TSDB block.
+func (c *checker) checkLayout() error {
+	var err error
+
+	snapshots := map[string]bool{}
+	blockFiles := map[string][]string{}
+
+	for _, name := range c.order {
+		snapshot, dir, rest := splitPath(name)
+
+		if dir == "" {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: file outside snapshot directory", ErrVerifyFailed, name))
+			continue
+		}
+
+		snapshots[snapshot] = true
+
+		if rest != "" {
+			key := path.Join(snapshot, dir)
+			blockFiles[key] = append(blockFiles[key], rest)
+		}
+	}
+
+	if len(snapshots) > 1 {
+		multierr.AppendInto(&err, fmt.Errorf("%w: multiple snapshot directories", ErrVerifyFailed))
+	}
+
+	for name := range snapshots {
+		c.snapshotName = name
+	}
+
+	if c.manifest != nil && len(snapshots) == 1 && !snapshots[c.manifest.SnapshotName] {
+		multierr.AppendInto(&err, fmt.Errorf("%w: snapshot directory doesn't match manifest name %q", ErrVerifyFailed, c.manifest.SnapshotName))
+	}
+
+	dirs := make([]string, 0, len(blockFiles))
+
+	for dir := range blockFiles {
+		dirs = append(dirs, dir)
+	}
+
+	slices.Sort(dirs)
+
+	for _, dir := range dirs {
+		multierr.AppendInto(&err, c.checkBlock(dir, blockFiles[dir]))
+	}
+
+	return err
+}
+
+// checkBlock validates a directory if it looks like a TSDB block. Directories
+// without metadata, index or chunks (e.g. the write-ahead log) are ignored.
+func (c *checker) checkBlock(dir string, files []string) error {
+	hasMeta := slices.Contains(files, "meta.json")
+	hasIndex := slices.Contains(files, "index")
+	hasChunks := slices.ContainsFunc(files, func(name string) bool {
+		return path.Dir(name) == "chunks"
+	})
+
+	if !(hasMeta || hasIndex || hasChunks) {
+		return nil
+	}
+
+	var err error
+
+	if !hasMeta {
+		multierr.AppendInto(&err, fmt.Errorf("%w: %s: block without meta.json", ErrVerifyFailed, dir))
+	} else {
+		multierr.AppendInto(&err, c.checkBlockMeta(dir))
+	}
+
+	if !hasIndex {
+		multierr.AppendInto(&err, fmt.Errorf("%w: %s: block without index", ErrVerifyFailed, dir))
+	} else {
+		name := path.Join(dir, "index")
+		f := c.files[name]
+
+		if f.size < int64(indexMinSize) {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: index too small (%d bytes)", ErrVerifyFailed, name, f.size))
+		} else if !bytes.HasPrefix(f.head, indexMagic) {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: invalid index header", ErrVerifyFailed, name))
+		}
+	}
+
+	for _, rest := range files {
+		if path.Dir(rest) != "chunks" {
+			continue
+		}
+
+		name := path.Join(dir, rest)
+		f := c.files[name]
+
+		if f.size < chunksHeaderSize {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: chunk file too small (%d bytes)", ErrVerifyFailed, name, f.size))
+		} else if !bytes.HasPrefix(f.head, chunksMagic) {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: invalid chunk file header", ErrVerifyFailed, name))
+		}
+	}
+
+	return err
+}
+
+func (c *checker) checkBlockMeta(dir string) error {
+	name := path.Join(dir, "meta.json")
+
+	var meta blockMeta
+
+	if err := json.Unmarshal(c.blockMeta[name], &meta); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrVerifyFailed, name, err)
+	}
+
+	if meta.ULID != path.Base(dir) {
+		return fmt.Errorf("%w: %s: block ID %q doesn't match directory", ErrVerifyFailed, name, meta.ULID)
+	}
+
+	if meta.Version != 1 {
+		return fmt.Errorf("%w: %s: unsupported version %d", ErrVerifyFailed, name, meta.Version)
+	}
+
+	if meta.MinTime >= meta.MaxTime {
+		return fmt.Errorf("%w: %s: invalid time range %d to %d", ErrVerifyFailed, name, meta.MinTime, meta.MaxTime)
+	}
+
+	c.blocks = append(c.blocks, api.ManifestBlock{
+		ULID:    meta.ULID,
+		MinTime: meta.MinTime,
+		MaxTime: meta.MaxTime,
+	})
+
+	return nil
+}
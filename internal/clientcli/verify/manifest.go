@@ -0,0 +1,170 @@
+package verify
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/minio/sha256-simd"
+	"go.uber.org/multierr"
+)
+
+var ErrVerifyFailed = errors.New("archive verification failed")
+
+// Maximum size of a manifest in bytes.
+const manifestMaxSize = 256 * 1024 * 1024
+
+type archiveFile struct {
+	size      int64
+	sha256Hex string
+
+	// Start of the file content, up to fileHeadSize bytes.
+	head []byte
+}
+
+// limitedBuffer retains written data up to a maximum size.
+type limitedBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - len(b.buf); remaining > 0 {
+		b.buf = append(b.buf, p[:min(remaining, len(p))]...)
+	}
+
+	return len(p), nil
+}
+
+// checker collects the files of an archive for comparison with the embedded
+// manifest and validation of the snapshot layout.
+type checker struct {
+	files     map[string]archiveFile
+	order     []string
+	blockMeta map[string][]byte
+	manifest  *api.Manifest
+
+	// Populated by checkLayout.
+	snapshotName string
+	blocks       []api.ManifestBlock
+}
+
+func newChecker() *checker {
+	return &checker{
+		files:     map[string]archiveFile{},
+		blockMeta: map[string][]byte{},
+	}
+}
+
+// isManifest returns whether the slash-separated path refers to the manifest
+// in the top-level snapshot directory.
+func isManifest(name string) bool {
+	dir := path.Dir(name)
+
+	return path.Base(name) == api.ManifestName && dir != "." && !strings.Contains(dir, "/")
+}
+
+// add records a regular file found in the archive.
+func (c *checker) add(name string, r io.Reader) error {
+	name = path.Clean(name)
+
+	if isManifest(name) {
+		if c.manifest != nil {
+			return fmt.Errorf("%w: multiple manifests", ErrVerifyFailed)
+		}
+
+		content, err := io.ReadAll(io.LimitReader(r, manifestMaxSize))
+		if err != nil {
+			return err
+		}
+
+		var m api.Manifest
+
+		if err := json.Unmarshal(content, &m); err != nil {
+			return fmt.Errorf("%w: parsing manifest %s: %w", ErrVerifyFailed, name, err)
+		}
+
+		c.manifest = &m
+
+		return nil
+	}
+
+	h := sha256.New()
+	head := &limitedBuffer{limit: fileHeadSize}
+	w := io.MultiWriter(h, head)
+
+	var meta *limitedBuffer
+
+	if _, _, rest := splitPath(name); rest == "meta.json" {
+		meta = &limitedBuffer{limit: blockMetaMaxSize}
+		w = io.MultiWriter(w, meta)
+	}
+
+	size, err := io.Copy(w, r)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrVerifyFailed, name, err)
+	}
+
+	if _, ok := c.files[name]; !ok {
+		c.order = append(c.order, name)
+	}
+
+	c.files[name] = archiveFile{
+		size:      size,
+		sha256Hex: hex.EncodeToString(h.Sum(nil)),
+		head:      head.buf,
+	}
+
+	if meta != nil {
+		c.blockMeta[name] = meta.buf
+	}
+
+	return nil
+}
+
+// checkManifest compares the archived files with the manifest. All
+// differences are reported. Archives created by other tools or older versions
+// don't contain a manifest; that's only an error if one is required.
+func (c *checker) checkManifest(required bool) error {
+	if c.manifest == nil {
+		if required {
+			return fmt.Errorf("%w: archive doesn't contain a manifest", ErrVerifyFailed)
+		}
+
+		return nil
+	}
+
+	if c.manifest.Version != api.ManifestVersion {
+		return fmt.Errorf("%w: unsupported manifest version %d", ErrVerifyFailed, c.manifest.Version)
+	}
+
+	var err error
+
+	listed := map[string]bool{}
+
+	for _, want := range c.manifest.Files {
+		name := path.Clean(want.Path)
+		listed[name] = true
+
+		if got, ok := c.files[name]; !ok {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: missing from archive", ErrVerifyFailed, name))
+		} else if got.size != want.Size {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: size mismatch (got %d, want %d)", ErrVerifyFailed, name, got.size, want.Size))
+		} else if got.sha256Hex != want.Sha256Hex {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: SHA256 mismatch (got %s, want %s)", ErrVerifyFailed, name, got.sha256Hex, want.Sha256Hex))
+		}
+	}
+
+	for _, name := range c.order {
+		if !listed[name] {
+			multierr.AppendInto(&err, fmt.Errorf("%w: %s: not listed in manifest", ErrVerifyFailed, name))
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,119 @@
+// Package digest computes digests over archives using one or more algorithms.
+package digest
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"slices"
+	"sync"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/minio/sha256-simd"
+	"github.com/zeebo/blake3"
+)
+
+var ErrUnknownAlgorithm = errors.New("unknown digest algorithm")
+
+// Writes smaller than this are hashed sequentially as the overhead of
+// starting goroutines would outweigh the gains.
+const parallelThreshold = 64 * 1024
+
+// New returns a hash implementing the given algorithm.
+func New(a api.DigestAlgorithm) (hash.Hash, error) {
+	switch a {
+	case api.DigestSha256:
+		return sha256.New(), nil
+
+	case api.DigestSha512:
+		return sha512.New(), nil
+
+	case api.DigestBlake3:
+		return blake3.New(), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, a)
+}
+
+// Set computes digests using multiple algorithms at once. Large writes are
+// hashed in parallel.
+type Set struct {
+	algorithms []api.DigestAlgorithm
+	hashes     []hash.Hash
+}
+
+// NewSet returns a set for the given algorithms. Duplicates are ignored.
+func NewSet(algorithms ...api.DigestAlgorithm) (*Set, error) {
+	s := &Set{}
+
+	for _, a := range algorithms {
+		if slices.Contains(s.algorithms, a) {
+			continue
+		}
+
+		h, err := New(a)
+		if err != nil {
+			return nil, err
+		}
+
+		s.algorithms = append(s.algorithms, a)
+		s.hashes = append(s.hashes, h)
+	}
+
+	return s, nil
+}
+
+// Algorithms returns the algorithms computed by the set.
+func (s *Set) Algorithms() []api.DigestAlgorithm {
+	return slices.Clone(s.algorithms)
+}
+
+// Write adds data to all digests. It never returns an error.
+func (s *Set) Write(p []byte) (int, error) {
+	if len(s.hashes) < 2 || len(p) < parallelThreshold {
+		for _, h := range s.hashes {
+			h.Write(p)
+		}
+
+		return len(p), nil
+	}
+
+	var wg sync.WaitGroup
+
+	for _, h := range s.hashes[1:] {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			h.Write(p)
+		}()
+	}
+
+	s.hashes[0].Write(p)
+
+	wg.Wait()
+
+	return len(p), nil
+}
+
+// Sum returns the digest for an algorithm or nil if it's not part of the set.
+func (s *Set) Sum(a api.DigestAlgorithm) []byte {
+	if idx := slices.Index(s.algorithms, a); idx >= 0 {
+		return s.hashes[idx].Sum(nil)
+	}
+
+	return nil
+}
+
+// HexSums returns the hex-encoded digests of all algorithms.
+func (s *Set) HexSums() map[api.DigestAlgorithm]string {
+	result := make(map[api.DigestAlgorithm]string, len(s.algorithms))
+
+	for idx, a := range s.algorithms {
+		result[a] = hex.EncodeToString(s.hashes[idx].Sum(nil))
+	}
+
+	return result
+}
@@ -0,0 +1,82 @@
+package digest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/prombackup/api"
+)
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		algorithm api.DigestAlgorithm
+		want      string
+	}{
+		{api.DigestSha256, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{api.DigestSha512, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+		{api.DigestBlake3, "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85"},
+	} {
+		t.Run(tc.algorithm.Name(), func(t *testing.T) {
+			h, err := New(tc.algorithm)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			h.Write([]byte("abc"))
+
+			if diff := cmp.Diff(tc.want, hex.EncodeToString(h.Sum(nil))); diff != "" {
+				t.Errorf("Digest diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewUnknown(t *testing.T) {
+	if _, err := NewSet(api.DigestSha256, "md5"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("NewSet() error = %v, want %v", err, ErrUnknownAlgorithm)
+	}
+}
+
+func TestSet(t *testing.T) {
+	s, err := NewSet(api.DigestBlake3, api.DigestSha256, api.DigestSha512, api.DigestSha256)
+	if err != nil {
+		t.Fatalf("NewSet() failed: %v", err)
+	}
+
+	if diff := cmp.Diff([]api.DigestAlgorithm{api.DigestBlake3, api.DigestSha256, api.DigestSha512}, s.Algorithms()); diff != "" {
+		t.Errorf("Algorithms() diff (-want +got):\n%s", diff)
+	}
+
+	// Mix of small and large writes to exercise both code paths
+	data := bytes.Repeat([]byte("0123456789abcdef"), 3*parallelThreshold/16)
+
+	for _, chunk := range [][]byte{data[:10], data[10 : 2*parallelThreshold], data[2*parallelThreshold:]} {
+		if n, err := s.Write(chunk); err != nil || n != len(chunk) {
+			t.Errorf("Write() = (%d, %v), want (%d, nil)", n, err, len(chunk))
+		}
+	}
+
+	want := map[api.DigestAlgorithm]string{}
+
+	for _, a := range api.DigestAlgorithmAll {
+		h, err := New(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h.Write(data)
+
+		want[a] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	if diff := cmp.Diff(want, s.HexSums()); diff != "" {
+		t.Errorf("HexSums() diff (-want +got):\n%s", diff)
+	}
+
+	if got := hex.EncodeToString(s.Sum(api.DigestSha512)); got != want[api.DigestSha512] {
+		t.Errorf("Sum() = %q, want %q", got, want[api.DigestSha512])
+	}
+}
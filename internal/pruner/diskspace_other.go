@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd)
+
+package pruner
+
+import "errors"
+
+func getDiskSpace(string) (diskSpace, error) {
+	return diskSpace{}, errors.New("querying free disk space is not supported on this platform")
+}
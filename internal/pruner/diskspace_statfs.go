@@ -0,0 +1,26 @@
+//go:build linux || darwin || freebsd
+
+package pruner
+
+import "golang.org/x/sys/unix"
+
+// getDiskSpace returns information about the space on the filesystem backing
+// the given path. Only space available to unprivileged users is considered
+// to be free.
+func getDiskSpace(path string) (diskSpace, error) {
+	var st unix.Statfs_t
+
+	if err := unix.Statfs(path, &st); err != nil {
+		return diskSpace{}, err
+	}
+
+	avail := int64(st.Bavail)
+	if avail < 0 {
+		avail = 0
+	}
+
+	return diskSpace{
+		Free:  uint64(avail) * uint64(st.Bsize),
+		Total: uint64(st.Blocks) * uint64(st.Bsize),
+	}, nil
+}
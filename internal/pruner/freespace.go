@@ -0,0 +1,146 @@
+package pruner
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+type diskSpace struct {
+	Free  uint64
+	Total uint64
+}
+
+func (o *Options) freeSpaceEnabled() bool {
+	return o.MinFreeBytes > 0 || o.MinFreePercent > 0
+}
+
+// freeSpaceSufficient reports whether the free space satisfies the configured
+// thresholds.
+func (o *Options) freeSpaceSufficient(ds diskSpace) bool {
+	if o.MinFreeBytes > 0 && ds.Free < o.MinFreeBytes {
+		return false
+	}
+
+	if o.MinFreePercent > 0 && (ds.Total == 0 || (100*float64(ds.Free)/float64(ds.Total)) < o.MinFreePercent) {
+		return false
+	}
+
+	return true
+}
+
+// pruneForFreeSpace removes the oldest snapshots kept by the retention rules
+// until the free space thresholds are satisfied. At least MinKeep snapshots
+// are always left in place. In a dry run the space freed by removals is
+// estimated. The reclaim counter must include all snapshots already removed.
+func (o *Options) pruneForFreeSpace(ctx context.Context, snapshots []snapshotInfo, reclaim *reclaimCounter, result *api.PruneResult) error {
+	if o.diskSpaceFunc == nil {
+		o.diskSpaceFunc = getDiskSpace
+	}
+
+	ds, err := o.diskSpaceFunc(o.Root)
+	if err != nil {
+		return fmt.Errorf("querying free disk space: %w", err)
+	}
+
+	if o.DryRun {
+		ds.Free += uint64(result.ReclaimedBytes)
+	}
+
+	kept := map[string]bool{}
+
+	for _, i := range result.Kept {
+		kept[i.Name] = true
+	}
+
+	var candidates []snapshotInfo
+
+	for _, info := range snapshots {
+		if kept[info.Name] {
+			candidates = append(candidates, info)
+		}
+	}
+
+	// Oldest first
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].Timestamp.Before(candidates[b].Timestamp)
+	})
+
+	remaining := len(snapshots) - len(result.Removed)
+
+	for _, info := range candidates {
+		if o.freeSpaceSufficient(ds) {
+			break
+		}
+
+		if remaining <= o.MinKeep {
+			o.Logger.Printf("Not removing more snapshots, minimum of %d reached", o.MinKeep)
+			break
+		}
+
+		if o.PreRemoveCheck != nil {
+			if err := o.PreRemoveCheck(info.Name); err != nil {
+				o.Logger.Printf("Not removing snapshot %s: %s", info.Name, err)
+				result.InUse = append(result.InUse, api.PruneSnapshot{
+					Name:   info.Name,
+					Reason: err.Error(),
+				})
+				continue
+			}
+		}
+
+		reclaimable, err := reclaim.add(filepath.Join(o.Root, filepath.Base(info.Name)))
+		if err != nil {
+			o.Logger.Printf("Determining size of snapshot %s: %v", info.Name, err)
+		}
+
+		if o.DryRun {
+			o.Logger.Printf("Would delete snapshot %s to free space", info.Name)
+			ds.Free += uint64(reclaimable)
+		} else {
+			o.Logger.Printf("Delete snapshot %s to free space", info.Name)
+
+			if err := Remove(o.Root, info.Name); err != nil {
+				return err
+			}
+
+			if ds, err = o.diskSpaceFunc(o.Root); err != nil {
+				return fmt.Errorf("querying free disk space: %w", err)
+			}
+		}
+
+		delete(kept, info.Name)
+		remaining--
+
+		result.Removed = append(result.Removed, api.PruneSnapshot{
+			Name:   info.Name,
+			Reason: "insufficient free space",
+		})
+		result.ReclaimedBytes += reclaimable
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+
+	if !o.freeSpaceSufficient(ds) {
+		o.Logger.Printf("Free space remains below threshold: %d of %d bytes free", ds.Free, ds.Total)
+	}
+
+	var stillKept []api.PruneSnapshot
+
+	for _, i := range result.Kept {
+		if kept[i.Name] {
+			stillKept = append(stillKept, i)
+		}
+	}
+
+	result.Kept = stillKept
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package pruner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+)
+
+func TestFreeSpaceSufficient(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts Options
+		ds   diskSpace
+		want bool
+	}{
+		{name: "disabled", want: true},
+		{
+			name: "bytes",
+			opts: Options{MinFreeBytes: 100},
+			ds:   diskSpace{Free: 100, Total: 1000},
+			want: true,
+		},
+		{
+			name: "bytes insufficient",
+			opts: Options{MinFreeBytes: 100},
+			ds:   diskSpace{Free: 99, Total: 1000},
+		},
+		{
+			name: "percent",
+			opts: Options{MinFreePercent: 12.5},
+			ds:   diskSpace{Free: 125, Total: 1000},
+			want: true,
+		},
+		{
+			name: "percent insufficient",
+			opts: Options{MinFreePercent: 12.5},
+			ds:   diskSpace{Free: 124, Total: 1000},
+		},
+		{
+			name: "percent unknown total",
+			opts: Options{MinFreePercent: 1},
+			ds:   diskSpace{Free: 124},
+		},
+		{
+			name: "both",
+			opts: Options{MinFreeBytes: 100, MinFreePercent: 50},
+			ds:   diskSpace{Free: 100, Total: 1000},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.opts.freeSpaceSufficient(tc.ds); got != tc.want {
+				t.Errorf("freeSpaceSufficient(%+v) returned %t, want %t", tc.ds, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPruneFreeSpace(t *testing.T) {
+	snapshotNames := []string{
+		"20220101T000000Z-a",
+		"20220102T000000Z-b",
+		"20220103T000000Z-c",
+		"20220104T000000Z-d",
+	}
+
+	// Every removed snapshot frees 40 bytes out of 1000.
+	fakeDiskSpace := func(root string) (diskSpace, error) {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return diskSpace{}, err
+		}
+
+		return diskSpace{
+			Free:  uint64(40 * (len(snapshotNames) - len(entries))),
+			Total: 1000,
+		}, nil
+	}
+
+	for _, tc := range []struct {
+		name          string
+		opts          Options
+		wantRemaining []string
+		wantResult    *api.PruneResult
+	}{
+		{
+			name: "bytes",
+			opts: Options{
+				KeepLast:     4,
+				MinFreeBytes: 80,
+			},
+			wantRemaining: snapshotNames[2:],
+			wantResult: &api.PruneResult{
+				Removed: []api.PruneSnapshot{
+					{Name: "20220101T000000Z-a", Reason: "insufficient free space"},
+					{Name: "20220102T000000Z-b", Reason: "insufficient free space"},
+				},
+				Kept: []api.PruneSnapshot{
+					{Name: "20220103T000000Z-c", Reason: "last"},
+					{Name: "20220104T000000Z-d", Reason: "last"},
+				},
+				ReclaimedBytes: 2 * 50,
+			},
+		},
+		{
+			name: "percent with minimum",
+			opts: Options{
+				KeepLast:       4,
+				MinFreePercent: 50,
+				MinKeep:        3,
+			},
+			wantRemaining: snapshotNames[1:],
+			wantResult: &api.PruneResult{
+				Removed: []api.PruneSnapshot{
+					{Name: "20220101T000000Z-a", Reason: "insufficient free space"},
+				},
+				Kept: []api.PruneSnapshot{
+					{Name: "20220102T000000Z-b", Reason: "last"},
+					{Name: "20220103T000000Z-c", Reason: "last"},
+					{Name: "20220104T000000Z-d", Reason: "last"},
+				},
+				ReclaimedBytes: 50,
+			},
+		},
+		{
+			name: "in use and retention",
+			opts: Options{
+				KeepLast:     3,
+				MinFreeBytes: 80,
+				PreRemoveCheck: func(name string) error {
+					if name == "20220102T000000Z-b" {
+						return errTest
+					}
+
+					return nil
+				},
+			},
+			wantRemaining: []string{
+				"20220102T000000Z-b",
+				"20220104T000000Z-d",
+			},
+			wantResult: &api.PruneResult{
+				Removed: []api.PruneSnapshot{
+					{Name: "20220101T000000Z-a", Reason: "not kept by any rule"},
+					{Name: "20220103T000000Z-c", Reason: "insufficient free space"},
+				},
+				Kept: []api.PruneSnapshot{
+					{Name: "20220102T000000Z-b", Reason: "last"},
+					{Name: "20220104T000000Z-d", Reason: "last"},
+				},
+				InUse: []api.PruneSnapshot{
+					{Name: "20220102T000000Z-b", Reason: errTest.Error()},
+				},
+				ReclaimedBytes: 2 * 50,
+			},
+		},
+		{
+			name: "dry run",
+			opts: Options{
+				KeepLast:     4,
+				MinFreeBytes: 100,
+				DryRun:       true,
+			},
+			wantRemaining: snapshotNames,
+			wantResult: &api.PruneResult{
+				DryRun: true,
+				Removed: []api.PruneSnapshot{
+					{Name: "20220101T000000Z-a", Reason: "insufficient free space"},
+					{Name: "20220102T000000Z-b", Reason: "insufficient free space"},
+				},
+				Kept: []api.PruneSnapshot{
+					{Name: "20220103T000000Z-c", Reason: "last"},
+					{Name: "20220104T000000Z-d", Reason: "last"},
+				},
+				ReclaimedBytes: 2 * 50,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+
+			for _, name := range snapshotNames {
+				if err := os.Mkdir(filepath.Join(root, name), 0o700); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(filepath.Join(root, name, "data"), make([]byte, 50), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tc.opts.Root = root
+			tc.opts.diskSpaceFunc = fakeDiskSpace
+			tc.opts.nowFunc = func() time.Time {
+				return time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+			}
+
+			result, err := Prune(context.Background(), tc.opts)
+			if err != nil {
+				t.Errorf("Prune() failed: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.wantResult, result, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(api.PruneResult{}, "Duration")); diff != "" {
+				t.Errorf("Result diff (-want +got):\n%s", diff)
+			}
+
+			var remaining []string
+
+			if entries, err := os.ReadDir(root); err != nil {
+				t.Errorf("ReadDir() failed: %v", err)
+			} else {
+				for _, entry := range entries {
+					remaining = append(remaining, entry.Name())
+				}
+			}
+
+			sort.Strings(remaining)
+
+			if diff := cmp.Diff(tc.wantRemaining, remaining, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Remaining entries diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
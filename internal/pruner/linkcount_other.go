@@ -0,0 +1,11 @@
+//go:build !unix
+
+package pruner
+
+import "io/fs"
+
+// linkCount returns the identity of a file and its number of hard links. Link
+// counts are not available on this platform.
+func linkCount(fs.FileInfo) (fileID, uint64, bool) {
+	return fileID{}, 0, false
+}
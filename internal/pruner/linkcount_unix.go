@@ -0,0 +1,17 @@
+//go:build unix
+
+package pruner
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// linkCount returns the identity of a file and its number of hard links.
+func linkCount(fi fs.FileInfo) (fileID, uint64, bool) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, uint64(st.Nlink), true
+	}
+
+	return fileID{}, 0, false
+}
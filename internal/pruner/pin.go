@@ -0,0 +1,60 @@
+package pruner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Pinned snapshots are marked by an empty file next to the snapshot
+// directory. Keeping the marker outside the snapshot ensures it doesn't end
+// up in archives.
+const pinSuffix = ".pinned"
+
+func pinPath(root, name string) string {
+	return filepath.Join(root, filepath.Base(name)+pinSuffix)
+}
+
+// Pin protects the named snapshot from being pruned.
+func Pin(root, name string) error {
+	f, err := os.OpenFile(pinPath(root, name), os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// Unpin removes the protection of the named snapshot. Unpinning a snapshot
+// which isn't pinned is not an error.
+func Unpin(root, name string) error {
+	if err := os.Remove(pinPath(root, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Remove deletes the named snapshot directory together with its pin marker,
+// if any. A marker left behind could otherwise only be removed manually.
+func Remove(root, name string) error {
+	if err := os.RemoveAll(filepath.Join(root, filepath.Base(name))); err != nil {
+		return err
+	}
+
+	return Unpin(root, name)
+}
+
+// IsPinned reports whether the named snapshot is protected from pruning.
+func IsPinned(root, name string) (bool, error) {
+	if _, err := os.Stat(pinPath(root, name)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,73 @@
+package pruner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPin(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	const name = "20221109T202035Z-355a5b4970d5a906"
+
+	check := func(want bool) {
+		t.Helper()
+
+		if got, err := IsPinned(tmpdir, name); err != nil {
+			t.Errorf("IsPinned() failed: %v", err)
+		} else if got != want {
+			t.Errorf("IsPinned() returned %v, want %v", got, want)
+		}
+	}
+
+	check(false)
+
+	for range 2 {
+		if err := Pin(tmpdir, name); err != nil {
+			t.Errorf("Pin() failed: %v", err)
+		}
+
+		check(true)
+	}
+
+	for range 2 {
+		if err := Unpin(tmpdir, name); err != nil {
+			t.Errorf("Unpin() failed: %v", err)
+		}
+
+		check(false)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	const name = "20221109T202035Z-355a5b4970d5a906"
+
+	if err := os.MkdirAll(filepath.Join(tmpdir, name, "block"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Pin(tmpdir, name); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := Remove(tmpdir, name); err != nil {
+			t.Errorf("Remove() failed: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(tmpdir, name)); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Snapshot directory not removed: %v", err)
+		}
+
+		if pinned, err := IsPinned(tmpdir, name); err != nil {
+			t.Errorf("IsPinned() failed: %v", err)
+		} else if pinned {
+			t.Errorf("Pin marker not removed")
+		}
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/hansmi/prombackup/api"
 )
 
 var errInvalidName = errors.New("invalid snapshot name")
@@ -41,58 +44,210 @@ func parseName(name string) (snapshotInfo, error) {
 	}, nil
 }
 
+// ParseTimestamp returns the creation time encoded in the name of a snapshot
+// directory as created by Prometheus.
+func ParseTimestamp(name string) (time.Time, error) {
+	info, err := parseName(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return info.Timestamp, nil
+}
+
 type Options struct {
-	Logger         Logger
-	Root           string
-	KeepWithin     time.Duration
+	Logger Logger
+	Root   string
+
+	// Keep all snapshots within this time interval.
+	KeepWithin time.Duration
+
+	// Keep the given number of most recent snapshots. The hourly, daily,
+	// weekly and monthly rules keep the most recent snapshot for the given
+	// number of distinct hours, days, ISO weeks or months (in UTC). All rules
+	// are combined; a snapshot is only removed if no rule keeps it.
+	KeepLast    int
+	KeepHourly  int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+
+	// After applying the retention rules remove the oldest remaining
+	// snapshots until the filesystem backing Root has at least the given
+	// number of bytes or percentage of its capacity available. Zero disables
+	// the respective threshold. At least MinKeep snapshots are always kept.
+	MinFreeBytes   uint64
+	MinFreePercent float64
+	MinKeep        int
+
 	PreRemoveCheck func(string) error
 
-	nowFunc func() time.Time
+	// Report what would be removed without deleting anything.
+	DryRun bool
+
+	nowFunc       func() time.Time
+	diskSpaceFunc func(string) (diskSpace, error)
 }
 
-func (o *Options) selectForDeletion(snapshots []snapshotInfo) []snapshotInfo {
+func (o *Options) validate() error {
+	if o.KeepWithin < 0 {
+		return fmt.Errorf("KeepWithin must be larger than or equal to zero")
+	}
+
+	for _, i := range []struct {
+		name  string
+		value int
+	}{
+		{"KeepLast", o.KeepLast},
+		{"KeepHourly", o.KeepHourly},
+		{"KeepDaily", o.KeepDaily},
+		{"KeepWeekly", o.KeepWeekly},
+		{"KeepMonthly", o.KeepMonthly},
+		{"MinKeep", o.MinKeep},
+	} {
+		if i.value < 0 {
+			return fmt.Errorf("%s must be larger than or equal to zero", i.name)
+		}
+	}
+
+	if !(o.MinFreePercent >= 0 && o.MinFreePercent <= 100) {
+		return fmt.Errorf("MinFreePercent must be between 0 and 100")
+	}
+
+	return nil
+}
+
+type bucketRule struct {
+	name  string
+	count int
+	key   func(time.Time) string
+}
+
+func (o *Options) bucketRules() []bucketRule {
+	return []bucketRule{
+		{"last", o.KeepLast, nil},
+		{"hourly", o.KeepHourly, func(ts time.Time) string {
+			return ts.UTC().Format("2006-01-02T15")
+		}},
+		{"daily", o.KeepDaily, func(ts time.Time) string {
+			return ts.UTC().Format("2006-01-02")
+		}},
+		{"weekly", o.KeepWeekly, func(ts time.Time) string {
+			year, week := ts.UTC().ISOWeek()
+			return fmt.Sprintf("%04d-W%02d", year, week)
+		}},
+		{"monthly", o.KeepMonthly, func(ts time.Time) string {
+			return ts.UTC().Format("2006-01")
+		}},
+	}
+}
+
+// selectForDeletion splits the given snapshots into those to be removed and
+// those kept by at least one rule. The reason for keeping a snapshot names all
+// applicable rules.
+func (o *Options) selectForDeletion(snapshots []snapshotInfo) ([]snapshotInfo, []api.PruneSnapshot) {
 	if o.nowFunc == nil {
 		o.nowFunc = time.Now
 	}
 
 	now := o.nowFunc()
 
-	keep := func(ts time.Time) bool {
-		if ts.IsZero() || now.Before(ts) {
-			return true
+	reasons := make([][]string, len(snapshots))
+
+	// Snapshots eligible for the count-based rules, newest first.
+	var candidates []int
+
+	for idx, info := range snapshots {
+		ts := info.Timestamp
+
+		switch {
+		case ts.IsZero():
+			reasons[idx] = append(reasons[idx], "unknown timestamp")
+		case now.Before(ts):
+			reasons[idx] = append(reasons[idx], "timestamp in the future")
+		default:
+			if now.Before(ts.Add(o.KeepWithin)) {
+				reasons[idx] = append(reasons[idx], fmt.Sprintf("within %v", o.KeepWithin))
+			}
+
+			candidates = append(candidates, idx)
 		}
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return snapshots[candidates[a]].Timestamp.After(snapshots[candidates[b]].Timestamp)
+	})
 
-		return now.Before(ts.Add(o.KeepWithin))
+	for _, rule := range o.bucketRules() {
+		remaining := rule.count
+		lastKey := ""
+
+		for _, idx := range candidates {
+			if remaining < 1 {
+				break
+			}
+
+			if rule.key != nil {
+				key := rule.key(snapshots[idx].Timestamp)
+				if key == lastKey {
+					continue
+				}
+
+				lastKey = key
+			}
+
+			reasons[idx] = append(reasons[idx], rule.name)
+			remaining--
+		}
 	}
 
-	var result []snapshotInfo
+	var remove []snapshotInfo
+	var keep []api.PruneSnapshot
 
-	for _, info := range snapshots {
-		if !keep(info.Timestamp) {
-			result = append(result, info)
+	for idx, info := range snapshots {
+		if len(reasons[idx]) == 0 {
+			remove = append(remove, info)
+		} else {
+			keep = append(keep, api.PruneSnapshot{
+				Name:   info.Name,
+				Reason: strings.Join(reasons[idx], ", "),
+			})
 		}
 	}
 
-	return result
+	return remove, keep
 }
 
-func Prune(ctx context.Context, opts Options) error {
+// Prune removes snapshots not kept by any of the configured rules. Pinned
+// snapshots are never removed and don't count towards any rule. The returned
+// result is populated even when an error occurs.
+func Prune(ctx context.Context, opts Options) (*api.PruneResult, error) {
+	result := &api.PruneResult{
+		DryRun: opts.DryRun,
+	}
+
 	if opts.Logger == nil {
 		opts.Logger = log.New(io.Discard, "", 0)
 	}
 
-	if opts.KeepWithin < 0 {
-		return fmt.Errorf("KeepWithin must be larger than or equal to zero")
+	start := time.Now()
+
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	if err := opts.validate(); err != nil {
+		return result, err
 	}
 
 	entries, err := os.ReadDir(opts.Root)
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return result, ctx.Err()
 	default:
 	}
 
@@ -106,34 +261,86 @@ func Prune(ctx context.Context, opts Options) error {
 		info, err := parseName(entry.Name())
 		if err != nil {
 			opts.Logger.Printf("Ignoring directory %s: %s", entry.Name(), err)
+			result.Invalid = append(result.Invalid, api.PruneSnapshot{
+				Name:   entry.Name(),
+				Reason: err.Error(),
+			})
+			continue
+		}
+
+		if pinned, err := IsPinned(opts.Root, info.Name); err != nil {
+			return result, err
+		} else if pinned {
+			result.Pinned = append(result.Pinned, api.PruneSnapshot{
+				Name:   info.Name,
+				Reason: "pinned",
+			})
 			continue
 		}
 
 		snapshots = append(snapshots, info)
 	}
 
-	for _, info := range opts.selectForDeletion(snapshots) {
+	remove, keep := opts.selectForDeletion(snapshots)
+
+	result.Kept = keep
+
+	reclaim := newReclaimCounter()
+
+	for _, info := range remove {
 		if opts.PreRemoveCheck != nil {
 			if err := opts.PreRemoveCheck(info.Name); err != nil {
 				opts.Logger.Printf("Not removing snapshot %s: %s", info.Name, err)
+				result.InUse = append(result.InUse, api.PruneSnapshot{
+					Name:   info.Name,
+					Reason: err.Error(),
+				})
 				continue
 			}
 		}
 
-		opts.Logger.Printf("Delete snapshot %s", info.Name)
+		removed := api.PruneSnapshot{
+			Name:   info.Name,
+			Reason: "not kept by any rule",
+		}
 
-		path := filepath.Join(opts.Root, filepath.Base(info.Name))
+		reclaimable, err := reclaim.add(filepath.Join(opts.Root, filepath.Base(info.Name)))
+		if err != nil {
+			opts.Logger.Printf("Determining size of snapshot %s: %v", info.Name, err)
+		}
 
-		if err := os.RemoveAll(path); err != nil {
-			return err
+		if opts.DryRun {
+			opts.Logger.Printf("Would delete snapshot %s", info.Name)
+			result.Removed = append(result.Removed, removed)
+			result.ReclaimedBytes += reclaimable
+			continue
 		}
 
+		opts.Logger.Printf("Delete snapshot %s", info.Name)
+
+		if err := Remove(opts.Root, info.Name); err != nil {
+			return result, err
+		}
+
+		result.Removed = append(result.Removed, removed)
+		result.ReclaimedBytes += reclaimable
+
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return result, ctx.Err()
 		default:
 		}
 	}
 
-	return nil
+	if opts.freeSpaceEnabled() {
+		if err := opts.pruneForFreeSpace(ctx, snapshots, reclaim, result); err != nil {
+			return result, err
+		}
+	}
+
+	if !opts.DryRun {
+		opts.Logger.Printf("Removed %d snapshots, reclaimed %d bytes", len(result.Removed), result.ReclaimedBytes)
+	}
+
+	return result, nil
 }
@@ -2,6 +2,7 @@ package pruner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,8 +12,11 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
 )
 
+var errTest = errors.New("test error")
+
 func TestParseName(t *testing.T) {
 	for _, tc := range []struct {
 		name    string
@@ -49,6 +53,18 @@ func TestParseName(t *testing.T) {
 	}
 }
 
+func TestParseTimestamp(t *testing.T) {
+	if got, err := ParseTimestamp("20221115T232711Z-7ef1661077569104"); err != nil {
+		t.Errorf("ParseTimestamp() failed: %v", err)
+	} else if want := time.Date(2022, 11, 15, 23, 27, 11, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("ParseTimestamp() returned %v, want %v", got, want)
+	}
+
+	if _, err := ParseTimestamp("bad"); !errors.Is(err, errInvalidName) {
+		t.Errorf("ParseTimestamp() returned %v, want %v", err, errInvalidName)
+	}
+}
+
 func TestSelectForDeletion(t *testing.T) {
 	for _, tc := range []struct {
 		name  string
@@ -130,9 +146,82 @@ func TestSelectForDeletion(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "keep last",
+			opts: Options{
+				KeepLast: 2,
+				nowFunc: func() time.Time {
+					return time.Date(2022, 1, 1, 13, 0, 0, 0, time.UTC)
+				},
+			},
+			input: []snapshotInfo{
+				{Name: "a", Timestamp: time.Date(2021, 12, 30, 0, 0, 0, 0, time.UTC)},
+				{Name: "b", Timestamp: time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC)},
+				{Name: "future", Timestamp: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+				{Name: "c", Timestamp: time.Date(2021, 12, 29, 0, 0, 0, 0, time.UTC)},
+				{Name: "d", Timestamp: time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)},
+			},
+			want: []snapshotInfo{
+				{Name: "a", Timestamp: time.Date(2021, 12, 30, 0, 0, 0, 0, time.UTC)},
+				{Name: "c", Timestamp: time.Date(2021, 12, 29, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "hourly",
+			opts: Options{
+				KeepHourly: 2,
+				nowFunc: func() time.Time {
+					return time.Date(2022, 1, 1, 13, 0, 0, 0, time.UTC)
+				},
+			},
+			input: []snapshotInfo{
+				{Name: "12:50", Timestamp: time.Date(2022, 1, 1, 12, 50, 0, 0, time.UTC)},
+				{Name: "12:10", Timestamp: time.Date(2022, 1, 1, 12, 10, 0, 0, time.UTC)},
+				{Name: "11:59", Timestamp: time.Date(2022, 1, 1, 11, 59, 0, 0, time.UTC)},
+				{Name: "11:00", Timestamp: time.Date(2022, 1, 1, 11, 0, 0, 0, time.UTC)},
+				{Name: "10:00", Timestamp: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)},
+			},
+			want: []snapshotInfo{
+				{Name: "12:10", Timestamp: time.Date(2022, 1, 1, 12, 10, 0, 0, time.UTC)},
+				{Name: "11:00", Timestamp: time.Date(2022, 1, 1, 11, 0, 0, 0, time.UTC)},
+				{Name: "10:00", Timestamp: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "combined",
+			opts: Options{
+				KeepWithin:  time.Hour,
+				KeepLast:    2,
+				KeepDaily:   3,
+				KeepWeekly:  2,
+				KeepMonthly: 3,
+				nowFunc: func() time.Time {
+					return time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+				},
+			},
+			input: []snapshotInfo{
+				{Name: "within", Timestamp: time.Date(2022, 3, 10, 11, 30, 0, 0, time.UTC)},
+				{Name: "last", Timestamp: time.Date(2022, 3, 10, 8, 0, 0, 0, time.UTC)},
+				{Name: "same day", Timestamp: time.Date(2022, 3, 10, 1, 0, 0, 0, time.UTC)},
+				{Name: "daily 2", Timestamp: time.Date(2022, 3, 9, 23, 0, 0, 0, time.UTC)},
+				{Name: "daily 2 early", Timestamp: time.Date(2022, 3, 9, 1, 0, 0, 0, time.UTC)},
+				{Name: "daily 3", Timestamp: time.Date(2022, 3, 7, 1, 0, 0, 0, time.UTC)},
+				{Name: "previous week", Timestamp: time.Date(2022, 3, 6, 1, 0, 0, 0, time.UTC)},
+				{Name: "february", Timestamp: time.Date(2022, 2, 20, 0, 0, 0, 0, time.UTC)},
+				{Name: "february early", Timestamp: time.Date(2022, 2, 2, 0, 0, 0, 0, time.UTC)},
+				{Name: "january", Timestamp: time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)},
+				{Name: "december", Timestamp: time.Date(2021, 12, 15, 0, 0, 0, 0, time.UTC)},
+			},
+			want: []snapshotInfo{
+				{Name: "same day", Timestamp: time.Date(2022, 3, 10, 1, 0, 0, 0, time.UTC)},
+				{Name: "daily 2 early", Timestamp: time.Date(2022, 3, 9, 1, 0, 0, 0, time.UTC)},
+				{Name: "february early", Timestamp: time.Date(2022, 2, 2, 0, 0, 0, 0, time.UTC)},
+				{Name: "december", Timestamp: time.Date(2021, 12, 15, 0, 0, 0, 0, time.UTC)},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			got := tc.opts.selectForDeletion(tc.input)
+			got, _ := tc.opts.selectForDeletion(tc.input)
 
 			if diff := cmp.Diff(tc.want, got, cmpopts.EquateEmpty()); diff != "" {
 				t.Errorf("selectForDeletion() diff (-want +got):\n%s", diff)
@@ -141,10 +230,42 @@ func TestSelectForDeletion(t *testing.T) {
 	}
 }
 
+func TestSelectForDeletionReasons(t *testing.T) {
+	opts := Options{
+		KeepWithin: time.Hour,
+		KeepLast:   1,
+		KeepDaily:  2,
+		nowFunc: func() time.Time {
+			return time.Date(2022, 1, 1, 13, 0, 0, 0, time.UTC)
+		},
+	}
+
+	_, got := opts.selectForDeletion([]snapshotInfo{
+		{Name: "zero"},
+		{Name: "future", Timestamp: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "12:30", Timestamp: time.Date(2022, 1, 1, 12, 30, 0, 0, time.UTC)},
+		{Name: "11:00", Timestamp: time.Date(2022, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{Name: "yesterday", Timestamp: time.Date(2021, 12, 31, 11, 0, 0, 0, time.UTC)},
+	})
+
+	want := []api.PruneSnapshot{
+		{Name: "zero", Reason: "unknown timestamp"},
+		{Name: "future", Reason: "timestamp in the future"},
+		{Name: "12:30", Reason: "within 1h0m0s, last, daily"},
+		{Name: "yesterday", Reason: "daily"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("selectForDeletion() diff (-want +got):\n%s", diff)
+	}
+}
+
 func TestPrune(t *testing.T) {
 	tmpdirAll := t.TempDir()
 	tmpdirSelective := t.TempDir()
 	tmpdirCheck := t.TempDir()
+	tmpdirDryRun := t.TempDir()
+	tmpdirPinned := t.TempDir()
 
 	for _, i := range []struct {
 		root    string
@@ -175,6 +296,23 @@ func TestPrune(t *testing.T) {
 				"20201020T000000Z-c",
 			},
 		},
+		{
+			root: tmpdirDryRun,
+			subdirs: []string{
+				"20181018T000000Z-a",
+				"20201020T000000Z-b",
+				"20211231T120000Z-c",
+				"invalid",
+			},
+		},
+		{
+			root: tmpdirPinned,
+			subdirs: []string{
+				"20181018T000000Z-a",
+				"20201020T000000Z-b",
+				"20211231T120000Z-c",
+			},
+		},
 	} {
 		for _, j := range i.subdirs {
 			if err := os.Mkdir(filepath.Join(i.root, j), 0o777); err != nil {
@@ -183,12 +321,29 @@ func TestPrune(t *testing.T) {
 		}
 	}
 
+	if err := os.WriteFile(filepath.Join(tmpdirDryRun, "20201020T000000Z-b", "data"), []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Pin(tmpdirPinned, "20181018T000000Z-a"); err != nil {
+		t.Fatal(err)
+	}
+
 	for _, tc := range []struct {
 		name          string
 		opts          Options
 		wantRemaining []string
+		wantResult    *api.PruneResult
 		wantErr       error
 	}{
+		{
+			name: "negative keep last",
+			opts: Options{
+				Root:     t.TempDir(),
+				KeepLast: -1,
+			},
+			wantErr: cmpopts.AnyError,
+		},
 		{
 			name: "empty",
 			opts: Options{
@@ -238,15 +393,96 @@ func TestPrune(t *testing.T) {
 				"20181018T000000Z-a",
 				"20201020T000000Z-c",
 			},
+			wantResult: &api.PruneResult{
+				Removed: []api.PruneSnapshot{
+					{Name: "20191019T000000Z-b", Reason: "not kept by any rule"},
+				},
+				InUse: []api.PruneSnapshot{
+					{Name: "20181018T000000Z-a", Reason: "fake error for 20181018T000000Z-a"},
+					{Name: "20201020T000000Z-c", Reason: "fake error for 20201020T000000Z-c"},
+				},
+			},
+		},
+		{
+			name: "dry run",
+			opts: Options{
+				Root:     tmpdirDryRun,
+				KeepLast: 1,
+				DryRun:   true,
+				PreRemoveCheck: func(name string) error {
+					if name == "20181018T000000Z-a" {
+						return errTest
+					}
+
+					return nil
+				},
+				nowFunc: func() time.Time {
+					return time.Date(2022, 1, 1, 13, 0, 0, 0, time.UTC)
+				},
+			},
+			wantRemaining: []string{
+				"20181018T000000Z-a",
+				"20201020T000000Z-b",
+				"20211231T120000Z-c",
+				"invalid",
+			},
+			wantResult: &api.PruneResult{
+				DryRun: true,
+				Removed: []api.PruneSnapshot{
+					{Name: "20201020T000000Z-b", Reason: "not kept by any rule"},
+				},
+				Kept: []api.PruneSnapshot{
+					{Name: "20211231T120000Z-c", Reason: "last"},
+				},
+				InUse: []api.PruneSnapshot{
+					{Name: "20181018T000000Z-a", Reason: "test error"},
+				},
+				Invalid: []api.PruneSnapshot{
+					{Name: "invalid", Reason: "invalid snapshot name: invalid"},
+				},
+				ReclaimedBytes: 5,
+			},
+		},
+		{
+			name: "pinned",
+			opts: Options{
+				Root:     tmpdirPinned,
+				KeepLast: 1,
+				nowFunc: func() time.Time {
+					return time.Date(2022, 1, 1, 13, 0, 0, 0, time.UTC)
+				},
+			},
+			wantRemaining: []string{
+				"20181018T000000Z-a",
+				"20181018T000000Z-a.pinned",
+				"20211231T120000Z-c",
+			},
+			wantResult: &api.PruneResult{
+				Removed: []api.PruneSnapshot{
+					{Name: "20201020T000000Z-b", Reason: "not kept by any rule"},
+				},
+				Kept: []api.PruneSnapshot{
+					{Name: "20211231T120000Z-c", Reason: "last"},
+				},
+				Pinned: []api.PruneSnapshot{
+					{Name: "20181018T000000Z-a", Reason: "pinned"},
+				},
+			},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			err := Prune(context.Background(), tc.opts)
+			result, err := Prune(context.Background(), tc.opts)
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
 			}
 
+			if tc.wantResult != nil {
+				if diff := cmp.Diff(tc.wantResult, result, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(api.PruneResult{}, "Duration")); diff != "" {
+					t.Errorf("Result diff (-want +got):\n%s", diff)
+				}
+			}
+
 			var remaining []string
 
 			if entries, err := os.ReadDir(tc.opts.Root); err != nil {
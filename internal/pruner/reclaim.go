@@ -0,0 +1,78 @@
+package pruner
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// fileID identifies a file independent of its paths.
+type fileID struct {
+	dev, ino uint64
+}
+
+type linkedFile struct {
+	seen    uint64
+	counted bool
+}
+
+// reclaimCounter estimates the number of bytes freed by removing a set of
+// snapshots. Snapshots consist of hard links into the TSDB and may share
+// files with each other. A file is only counted once all of its links have
+// been seen in removed snapshots. All regular files are counted where link
+// counts are not available.
+type reclaimCounter struct {
+	files map[fileID]*linkedFile
+}
+
+func newReclaimCounter() *reclaimCounter {
+	return &reclaimCounter{
+		files: map[fileID]*linkedFile{},
+	}
+}
+
+// add records the removal of the given directory and returns the number of
+// bytes freed in addition to previously added directories. Directories may be
+// added before or after they're actually removed.
+func (c *reclaimCounter) add(path string) (int64, error) {
+	var total int64
+
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		id, count, ok := linkCount(fi)
+		if !ok {
+			total += fi.Size()
+			return nil
+		}
+
+		f := c.files[id]
+		if f == nil {
+			f = &linkedFile{}
+			c.files[id] = f
+		}
+
+		f.seen++
+
+		// The link count shrinks when snapshots containing the file have
+		// already been removed.
+		if !f.counted && f.seen >= count {
+			f.counted = true
+			total += fi.Size()
+		}
+
+		return nil
+	})
+
+	return total, err
+}
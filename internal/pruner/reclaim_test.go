@@ -0,0 +1,137 @@
+package pruner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// hardLinksSupported reports whether link counts are available for files in
+// the given directory.
+func hardLinksSupported(t *testing.T, dir string) bool {
+	t.Helper()
+
+	src := filepath.Join(dir, "link-src")
+	dst := filepath.Join(dir, "link-dst")
+
+	if err := os.WriteFile(src, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(src)
+
+	if err := os.Link(src, dst); err != nil {
+		return false
+	}
+
+	defer os.Remove(dst)
+
+	fi, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, ok := linkCount(fi)
+
+	return ok
+}
+
+func TestReclaimableBytes(t *testing.T) {
+	tmpdir := t.TempDir()
+	snapshot := filepath.Join(tmpdir, "snapshot")
+
+	if err := os.MkdirAll(filepath.Join(snapshot, "block", "chunks"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, size := range map[string]int{
+		"block/index":         100,
+		"block/chunks/000001": 1000,
+		"block/meta.json":     10,
+	} {
+		if err := os.WriteFile(filepath.Join(snapshot, path), make([]byte, size), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := int64(1110)
+
+	if hardLinksSupported(t, tmpdir) {
+		if err := os.Link(filepath.Join(snapshot, "block", "chunks", "000001"), filepath.Join(tmpdir, "tsdb-chunk")); err != nil {
+			t.Fatal(err)
+		}
+
+		// Hard-linked file is still referenced from elsewhere
+		want -= 1000
+	}
+
+	if got, err := newReclaimCounter().add(snapshot); err != nil {
+		t.Errorf("add() failed: %v", err)
+	} else if got != want {
+		t.Errorf("add() returned %d, want %d", got, want)
+	}
+
+	if _, err := newReclaimCounter().add(filepath.Join(tmpdir, "missing")); err == nil {
+		t.Error("add() succeeded for missing directory")
+	}
+}
+
+func TestReclaimableBytesShared(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	if !hardLinksSupported(t, tmpdir) {
+		t.Skip("Hard links not supported")
+	}
+
+	first := filepath.Join(tmpdir, "first")
+	second := filepath.Join(tmpdir, "second")
+
+	for _, dir := range []string{first, second} {
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "own"), make([]byte, 10), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Shared only between the two snapshots
+	if err := os.WriteFile(filepath.Join(first, "shared"), make([]byte, 1000), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Link(filepath.Join(first, "shared"), filepath.Join(second, "shared")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		remove bool
+	}{
+		{name: "dry run"},
+		{name: "removal", remove: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newReclaimCounter()
+
+			if got, err := c.add(first); err != nil {
+				t.Errorf("add() failed: %v", err)
+			} else if got != 10 {
+				t.Errorf("add() returned %d for first snapshot, want 10", got)
+			}
+
+			if tc.remove {
+				if err := os.RemoveAll(first); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got, err := c.add(second); err != nil {
+				t.Errorf("add() failed: %v", err)
+			} else if got != 1010 {
+				t.Errorf("add() returned %d for second snapshot, want 1010", got)
+			}
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package snapshotstream
 
 import (
+	"context"
 	"io"
 	"io/fs"
 	"path/filepath"
 
+	"github.com/hansmi/prombackup/api"
 	"go.uber.org/multierr"
 )
 
@@ -12,23 +14,73 @@ type openFunc func() (io.ReadCloser, error)
 
 type archiver interface {
 	io.Closer
-	Append(string, fs.DirEntry, openFunc) error
+	Append(context.Context, string, fs.DirEntry, openFunc) error
 	FileErrors() error
 }
 
-func archiveDir(root fs.FS, base string, a archiver) error {
+// contextReader fails all reads once the context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if r.ctx.Err() != nil {
+		return 0, context.Cause(r.ctx)
+	}
+
+	return r.Reader.Read(p)
+}
+
+// archiveDir appends all entries below root to the archive and returns
+// a manifest of all archived files. Progress is reported to p if it's not nil.
+// The walk stops as soon as the context is cancelled.
+func archiveDir(ctx context.Context, root fs.FS, base string, a archiver, p *progress) (*api.Manifest, error) {
+	mb := newManifestBuilder(base)
+
 	err := fs.WalkDir(root, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			// Directory walk failed
 			return err
 		}
 
-		return a.Append(filepath.Join(base, path), d, func() (io.ReadCloser, error) {
-			return root.Open(path)
-		})
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+
+		name := filepath.Join(base, path)
+
+		p.setCurrentFile(name)
+
+		var content *manifestReader
+
+		if err := a.Append(ctx, name, d, func() (io.ReadCloser, error) {
+			fh, err := root.Open(path)
+			if err != nil {
+				return nil, err
+			}
+
+			content = mb.wrapReader(path, p.wrapReader(fh))
+
+			return content, nil
+		}); err != nil {
+			return err
+		}
+
+		if content != nil {
+			mb.addFile(name, d, content)
+		}
+
+		if d.Type().IsRegular() {
+			p.fileDone()
+		}
+
+		return nil
 	})
 
+	p.setCurrentFile("")
+
 	multierr.AppendInto(&err, a.FileErrors())
 
-	return err
+	return mb.manifest(), err
 }
@@ -2,19 +2,23 @@ package snapshotstream
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"io/fs"
 	"path/filepath"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
 )
 
 type archiveEntry struct {
 	name    string
 	mode    fs.FileMode
+	modTime time.Time
 	data    string
 	wantErr error
 }
@@ -24,8 +28,9 @@ func (e *archiveEntry) args(t *testing.T) (fs.DirEntry, openFunc) {
 
 	fs := fstest.MapFS{
 		e.name: {
-			Mode: e.mode,
-			Data: []byte(e.data),
+			Mode:    e.mode,
+			ModTime: e.modTime,
+			Data:    []byte(e.data),
 		},
 	}
 
@@ -50,7 +55,7 @@ func (tc archiverTest) do(t *testing.T, a archiver) {
 	for _, e := range tc.entries {
 		d, open := e.args(t)
 
-		err := a.Append(e.name, d, open)
+		err := a.Append(context.Background(), e.name, d, open)
 
 		if diff := cmp.Diff(e.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 			t.Errorf("Append() error diff (-want +got):\n%s", diff)
@@ -68,10 +73,11 @@ func (tc archiverTest) do(t *testing.T, a archiver) {
 
 func TestArchiveDir(t *testing.T) {
 	for _, tc := range []struct {
-		name string
-		root fs.FS
-		base string
-		want []tarEntry
+		name         string
+		root         fs.FS
+		base         string
+		want         []tarEntry
+		wantManifest *api.Manifest
 	}{
 		{
 			name: "empty",
@@ -79,6 +85,9 @@ func TestArchiveDir(t *testing.T) {
 			want: []tarEntry{
 				{name: "."},
 			},
+			wantManifest: &api.Manifest{
+				Version: api.ManifestVersion,
+			},
 		},
 		{
 			name: "nested",
@@ -110,6 +119,84 @@ func TestArchiveDir(t *testing.T) {
 					content: "root",
 				},
 			},
+			wantManifest: &api.Manifest{
+				Version:      api.ManifestVersion,
+				SnapshotName: "base",
+				Files: []api.ManifestFile{
+					{
+						Path:      "base/dir/aaa.txt",
+						Size:      3,
+						Sha256Hex: "9834876dcfb05cb167a5c24953eba58c4ac89b1adf57f28f2f9d09af107ee8f0",
+					},
+					{
+						Path:      "base/root.txt",
+						Size:      4,
+						Sha256Hex: "4813494d137e1631bba301d5acab6e7bb7aa74ce1185d456565ef51d737677b2",
+					},
+				},
+			},
+		},
+		{
+			name: "blocks",
+			root: &fstest.MapFS{
+				"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json": {
+					Data:    []byte(`{"ulid": "01GHCE3ZV1VQ4M6F2XJ0BHZ4RA", "minTime": 1667952000000, "maxTime": 1667959200000}`),
+					ModTime: time.Date(2022, time.November, 9, 20, 20, 35, 123, time.UTC),
+				},
+				"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index": {
+					Data: []byte("index"),
+				},
+				"bad/meta.json": {
+					Data: []byte("{"),
+				},
+				"meta.json": {
+					Data: []byte(`{"ulid": "ignored"}`),
+				},
+			},
+			base: "snap",
+			want: []tarEntry{
+				{name: "snap"},
+				{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA"},
+				{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index", content: "index"},
+				{name: "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json", content: `{"ulid": "01GHCE3ZV1VQ4M6F2XJ0BHZ4RA", "minTime": 1667952000000, "maxTime": 1667959200000}`},
+				{name: "snap/bad"},
+				{name: "snap/bad/meta.json", content: "{"},
+				{name: "snap/meta.json", content: `{"ulid": "ignored"}`},
+			},
+			wantManifest: &api.Manifest{
+				Version:      api.ManifestVersion,
+				SnapshotName: "snap",
+				Files: []api.ManifestFile{
+					{
+						Path:      "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/index",
+						Size:      5,
+						Sha256Hex: "1bc04b5291c26a46d918139138b992d2de976d6851d0893b0476b85bfbdfc6e6",
+					},
+					{
+						Path:      "snap/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json",
+						Size:      90,
+						ModTime:   time.Date(2022, time.November, 9, 20, 20, 35, 0, time.UTC),
+						Sha256Hex: "46695263538f634a1a2072f3040e92e23c020438d6f3a2d04505b2d362c6a2e9",
+					},
+					{
+						Path:      "snap/bad/meta.json",
+						Size:      1,
+						Sha256Hex: "021fb596db81e6d02bf3d2586ee3981fe519f275c0ac9ca76bbcf2ebb4097d96",
+					},
+					{
+						Path:      "snap/meta.json",
+						Size:      19,
+						Sha256Hex: "5a0825035b4d4a30e210d59d7302022ed54205c2e679d73186d14fc264b639d8",
+					},
+				},
+				Blocks: []api.ManifestBlock{
+					{
+						ULID:    "01GHCE3ZV1VQ4M6F2XJ0BHZ4RA",
+						MinTime: 1667952000000,
+						MaxTime: 1667959200000,
+					},
+				},
+			},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
@@ -117,11 +204,15 @@ func TestArchiveDir(t *testing.T) {
 
 			a := newTarArchiver(&buf, nil)
 
-			err := archiveDir(tc.root, tc.base, a)
+			manifest, err := archiveDir(context.Background(), tc.root, tc.base, a, nil)
 			if err != nil {
 				t.Errorf("archiveDir() failed: %v", err)
 			}
 
+			if diff := cmp.Diff(tc.wantManifest, manifest, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Manifest diff (-want +got):\n%s", diff)
+			}
+
 			if err := a.Close(); err != nil {
 				t.Errorf("Close() failed: %v", err)
 			}
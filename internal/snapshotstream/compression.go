@@ -0,0 +1,105 @@
+package snapshotstream
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+var ErrCompressionOptions = errors.New("invalid compression options")
+
+// CompressionOptions tune the compression of an archive. Zero values select
+// the defaults of the archive format.
+type CompressionOptions struct {
+	// Compression level. Higher levels trade CPU time for a smaller output.
+	// Zstandard accepts levels 1 to 22, gzip, LZ4, bzip2 and ZIP accept 1 to
+	// 9.
+	Level int
+
+	// Zstandard window size in bytes. Must be a power of two.
+	Window int
+
+	// Number of goroutines used by the gzip and Zstandard encoders.
+	Concurrency int
+}
+
+// Size of the blocks compressed in parallel by the gzip encoder.
+const gzipBlockSize = 1024 * 1024
+
+// lz4Levels maps compression levels 1 to 9 to their LZ4 equivalent.
+var lz4Levels = [...]lz4.CompressionLevel{
+	lz4.Level1, lz4.Level2, lz4.Level3, lz4.Level4, lz4.Level5,
+	lz4.Level6, lz4.Level7, lz4.Level8, lz4.Level9,
+}
+
+// levelRange returns the range of compression levels supported by an archive
+// format.
+func levelRange(f api.ArchiveFormat) (minLevel, maxLevel int, ok bool) {
+	switch f {
+	case api.ArchiveTarGzip, api.ArchiveTarLz4, api.ArchiveTarBzip2, api.ArchiveZip:
+		return 1, 9, true
+
+	case api.ArchiveTarZstd:
+		return 1, 22, true
+	}
+
+	return 0, 0, false
+}
+
+// supportsConcurrency returns whether the encoder of an archive format can use
+// multiple goroutines.
+func supportsConcurrency(f api.ArchiveFormat) bool {
+	return f == api.ArchiveTarGzip || f == api.ArchiveTarZstd
+}
+
+func (o CompressionOptions) validate(f api.ArchiveFormat) error {
+	if o.Level < 0 || o.Window < 0 || o.Concurrency < 0 {
+		return fmt.Errorf("%w: values must not be negative", ErrCompressionOptions)
+	}
+
+	if o.Level != 0 {
+		if minLevel, maxLevel, ok := levelRange(f); !ok {
+			return fmt.Errorf("%w: format %s doesn't support a compression level", ErrCompressionOptions, f)
+		} else if o.Level < minLevel || o.Level > maxLevel {
+			return fmt.Errorf("%w: level for format %s must be between %d and %d", ErrCompressionOptions, f, minLevel, maxLevel)
+		}
+	}
+
+	if o.Window != 0 && f != api.ArchiveTarZstd {
+		return fmt.Errorf("%w: window is only supported for format %s", ErrCompressionOptions, api.ArchiveTarZstd)
+	}
+
+	if o.Concurrency != 0 && !supportsConcurrency(f) {
+		return fmt.Errorf("%w: concurrency is only supported for formats %s and %s", ErrCompressionOptions, api.ArchiveTarGzip, api.ArchiveTarZstd)
+	}
+
+	if o.Window != 0 && (o.Window < zstd.MinWindowSize || o.Window > zstd.MaxWindowSize || o.Window&(o.Window-1) != 0) {
+		return fmt.Errorf("%w: window must be a power of two between %d and %d", ErrCompressionOptions, zstd.MinWindowSize, zstd.MaxWindowSize)
+	}
+
+	return nil
+}
+
+// zstdOptions returns the encoder options for Zstandard.
+func (o CompressionOptions) zstdOptions() []zstd.EOption {
+	result := []zstd.EOption{
+		zstd.WithEncoderCRC(true),
+	}
+
+	if o.Level != 0 {
+		result = append(result, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(o.Level)))
+	}
+
+	if o.Window != 0 {
+		result = append(result, zstd.WithWindowSize(o.Window))
+	}
+
+	if o.Concurrency != 0 {
+		result = append(result, zstd.WithEncoderConcurrency(o.Concurrency))
+	}
+
+	return result
+}
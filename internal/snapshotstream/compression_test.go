@@ -0,0 +1,211 @@
+package snapshotstream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+)
+
+func TestCompressionOptionsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		format  api.ArchiveFormat
+		opts    CompressionOptions
+		wantErr error
+	}{
+		{name: "defaults", format: api.ArchiveTar},
+		{name: "gzip level", format: api.ArchiveTarGzip, opts: CompressionOptions{Level: 9}},
+		{name: "zstd level", format: api.ArchiveTarZstd, opts: CompressionOptions{Level: 22}},
+		{
+			name:   "zstd all",
+			format: api.ArchiveTarZstd,
+			opts: CompressionOptions{
+				Level:       3,
+				Window:      1 << 20,
+				Concurrency: 2,
+			},
+		},
+		{
+			name:    "negative",
+			format:  api.ArchiveTarGzip,
+			opts:    CompressionOptions{Level: -1},
+			wantErr: ErrCompressionOptions,
+		},
+		{
+			name:    "gzip level too high",
+			format:  api.ArchiveTarGzip,
+			opts:    CompressionOptions{Level: 10},
+			wantErr: ErrCompressionOptions,
+		},
+		{
+			name:    "tar level",
+			format:  api.ArchiveTar,
+			opts:    CompressionOptions{Level: 1},
+			wantErr: ErrCompressionOptions,
+		},
+		{
+			name:    "xz level",
+			format:  api.ArchiveTarXz,
+			opts:    CompressionOptions{Level: 1},
+			wantErr: ErrCompressionOptions,
+		},
+		{
+			name:    "gzip window",
+			format:  api.ArchiveTarGzip,
+			opts:    CompressionOptions{Window: 1 << 20},
+			wantErr: ErrCompressionOptions,
+		},
+		{
+			name:   "gzip concurrency",
+			format: api.ArchiveTarGzip,
+			opts:   CompressionOptions{Level: 6, Concurrency: 4},
+		},
+		{
+			name:    "lz4 concurrency",
+			format:  api.ArchiveTarLz4,
+			opts:    CompressionOptions{Concurrency: 4},
+			wantErr: ErrCompressionOptions,
+		},
+		{
+			name:    "window not power of two",
+			format:  api.ArchiveTarZstd,
+			opts:    CompressionOptions{Window: 3000},
+			wantErr: ErrCompressionOptions,
+		},
+		{
+			name:    "window too small",
+			format:  api.ArchiveTarZstd,
+			opts:    CompressionOptions{Window: 512},
+			wantErr: ErrCompressionOptions,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.validate(tc.format)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("validate() error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStreamCompressionLevels(t *testing.T) {
+	root := fstest.MapFS{
+		"file": {Data: []byte("hello world")},
+	}
+
+	for _, format := range api.ArchiveFormatAll {
+		minLevel, maxLevel, ok := levelRange(format)
+		if !ok {
+			continue
+		}
+
+		for _, level := range []int{minLevel, maxLevel} {
+			t.Run(fmt.Sprintf("%s/%d", format, level), func(t *testing.T) {
+				s, err := New(Options{
+					Name:   "levels",
+					Root:   root,
+					Format: format,
+					Compression: CompressionOptions{
+						Level: level,
+					},
+				})
+				if err != nil {
+					t.Fatalf("New() failed: %v", err)
+				}
+
+				if err := s.WriteArchive(context.Background(), io.Discard); err != nil {
+					t.Errorf("WriteArchive() failed: %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestStreamParallelGzip(t *testing.T) {
+	root := fstest.MapFS{
+		"small": {Data: []byte("hello")},
+		"large": {Data: bytes.Repeat([]byte("0123456789"), 512*1024)},
+		"more":  {Data: bytes.Repeat([]byte("abc"), 1024*1024)},
+	}
+
+	for _, tc := range []struct {
+		name               string
+		compression        CompressionOptions
+		defaultConcurrency int
+		wantConcurrency    int
+	}{
+		{name: "encoder default"},
+		{
+			name:               "server default",
+			defaultConcurrency: 3,
+			wantConcurrency:    3,
+		},
+		{
+			name:               "requested",
+			compression:        CompressionOptions{Level: 1, Concurrency: 2},
+			defaultConcurrency: 3,
+			wantConcurrency:    2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(Options{
+				Name:               "parallel",
+				Root:               root,
+				Format:             api.ArchiveTarGzip,
+				Compression:        tc.compression,
+				DefaultConcurrency: tc.defaultConcurrency,
+			})
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			if got := s.compression.Concurrency; got != tc.wantConcurrency {
+				t.Errorf("Concurrency is %d, want %d", got, tc.wantConcurrency)
+			}
+
+			var buf bytes.Buffer
+
+			if err := s.WriteArchive(context.Background(), &buf); err != nil {
+				t.Fatalf("WriteArchive() failed: %v", err)
+			}
+
+			gzReader, err := gzip.NewReader(&buf)
+			if err != nil {
+				t.Fatalf("gzip.NewReader() failed: %v", err)
+			}
+
+			checkTarContents(t, gzReader, []tarEntry{
+				{name: "parallel"},
+				{name: "parallel/large", content: string(root["large"].Data)},
+				{name: "parallel/more", content: string(root["more"].Data)},
+				{name: "parallel/small", content: "hello"},
+				{name: "parallel/MANIFEST.json"},
+			})
+		})
+	}
+}
+
+func TestNewIgnoresDefaultConcurrency(t *testing.T) {
+	s, err := New(Options{
+		Name:               "tar",
+		Root:               fstest.MapFS{},
+		Format:             api.ArchiveTar,
+		DefaultConcurrency: 4,
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if got := s.compression.Concurrency; got != 0 {
+		t.Errorf("Concurrency is %d, want 0", got)
+	}
+}
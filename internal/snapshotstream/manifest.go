@@ -0,0 +1,160 @@
+package snapshotstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/hansmi/prombackup/api"
+	"github.com/minio/sha256-simd"
+)
+
+// Maximum size of TSDB block metadata files parsed for the manifest.
+const blockMetaMaxSize = 1024 * 1024
+
+// isBlockMeta returns whether a path relative to the snapshot directory refers
+// to the metadata file of a TSDB block.
+func isBlockMeta(p string) bool {
+	return path.Base(p) == "meta.json" && path.Dir(p) != "." && path.Dir(path.Dir(p)) == "."
+}
+
+// manifestReader computes the digest of file content while it's read. The
+// content of TSDB block metadata files is retained for parsing.
+type manifestReader struct {
+	io.ReadCloser
+	hash hash.Hash
+	size int64
+	meta *bytes.Buffer
+}
+
+func (r *manifestReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+
+	if r.meta != nil && r.meta.Len() < blockMetaMaxSize {
+		r.meta.Write(p[:n])
+	}
+
+	return n, err
+}
+
+type manifestBuilder struct {
+	m api.Manifest
+}
+
+func newManifestBuilder(snapshotName string) *manifestBuilder {
+	return &manifestBuilder{
+		m: api.Manifest{
+			Version:      api.ManifestVersion,
+			SnapshotName: snapshotName,
+			Files:        []api.ManifestFile{},
+			Blocks:       []api.ManifestBlock{},
+		},
+	}
+}
+
+// wrapReader returns a reader recording the digest of the content read from
+// the file at the given path relative to the snapshot directory.
+func (b *manifestBuilder) wrapReader(p string, r io.ReadCloser) *manifestReader {
+	mr := &manifestReader{
+		ReadCloser: r,
+		hash:       sha256.New(),
+	}
+
+	if isBlockMeta(p) {
+		mr.meta = &bytes.Buffer{}
+	}
+
+	return mr
+}
+
+// addFile records a file archived using content from the given reader. TSDB
+// block metadata which can't be parsed is ignored as the file itself is still
+// listed.
+func (b *manifestBuilder) addFile(name string, d fs.DirEntry, r *manifestReader) {
+	f := api.ManifestFile{
+		Path:      filepath.ToSlash(filepath.Clean(name)),
+		Size:      r.size,
+		Sha256Hex: hex.EncodeToString(r.hash.Sum(nil)),
+	}
+
+	if fi, err := d.Info(); err == nil {
+		f.ModTime = fi.ModTime().UTC().Truncate(time.Second)
+	}
+
+	b.m.Files = append(b.m.Files, f)
+
+	if r.meta == nil {
+		return
+	}
+
+	var meta struct {
+		ULID    string `json:"ulid"`
+		MinTime int64  `json:"minTime"`
+		MaxTime int64  `json:"maxTime"`
+	}
+
+	if err := json.Unmarshal(r.meta.Bytes(), &meta); err == nil && meta.ULID != "" {
+		b.m.Blocks = append(b.m.Blocks, api.ManifestBlock{
+			ULID:    meta.ULID,
+			MinTime: meta.MinTime,
+			MaxTime: meta.MaxTime,
+		})
+	}
+}
+
+func (b *manifestBuilder) manifest() *api.Manifest {
+	return &b.m
+}
+
+// manifestFileInfo describes the manifest file for archivers.
+type manifestFileInfo struct {
+	size    int64
+	modTime time.Time
+}
+
+func (fi manifestFileInfo) Name() string       { return api.ManifestName }
+func (fi manifestFileInfo) Size() int64        { return fi.size }
+func (fi manifestFileInfo) Mode() fs.FileMode  { return 0o644 }
+func (fi manifestFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi manifestFileInfo) IsDir() bool        { return false }
+func (fi manifestFileInfo) Sys() any           { return nil }
+
+// appendManifest writes the manifest as the last file of the archive. Its
+// modification time is the most recent of all files to keep the output
+// reproducible.
+func appendManifest(ctx context.Context, a archiver, base string, m *api.Manifest) error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	content = append(content, '\n')
+
+	fi := manifestFileInfo{
+		size: int64(len(content)),
+	}
+
+	for _, f := range m.Files {
+		if f.ModTime.After(fi.modTime) {
+			fi.modTime = f.ModTime
+		}
+	}
+
+	if err := a.Append(ctx, filepath.Join(base, api.ManifestName), fs.FileInfoToDirEntry(fi), func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}); err != nil {
+		return err
+	}
+
+	return a.FileErrors()
+}
@@ -0,0 +1,101 @@
+package snapshotstream
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/prombackup/api"
+)
+
+func TestStreamManifest(t *testing.T) {
+	modTime := time.Date(2022, time.November, 9, 20, 20, 35, 0, time.UTC)
+
+	s, err := New(Options{
+		Name: "manifest",
+		Root: fstest.MapFS{
+			"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json": {
+				Data:    []byte(`{"ulid": "01GHCE3ZV1VQ4M6F2XJ0BHZ4RA", "minTime": 1000, "maxTime": 2000}`),
+				ModTime: modTime,
+			},
+			"01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/chunks/000001": {
+				Data:    []byte("chunk"),
+				ModTime: modTime.Add(-time.Hour),
+			},
+		},
+		Format: api.ArchiveTar,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := s.WriteArchive(context.Background(), &buf); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	var hdr *tar.Header
+	var content []byte
+
+	for tr := tar.NewReader(&buf); ; {
+		next, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+
+		hdr = next
+
+		if content, err = io.ReadAll(tr); err != nil {
+			t.Fatalf("ReadAll() failed: %v", err)
+		}
+	}
+
+	if hdr == nil || hdr.Name != "manifest/MANIFEST.json" {
+		t.Fatalf("Last archive entry is %+v, want manifest", hdr)
+	}
+
+	if !hdr.ModTime.Equal(modTime) {
+		t.Errorf("Manifest modification time is %v, want %v", hdr.ModTime, modTime)
+	}
+
+	var got api.Manifest
+
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+
+	want := api.Manifest{
+		Version:      api.ManifestVersion,
+		SnapshotName: "manifest",
+		Files: []api.ManifestFile{
+			{
+				Path:      "manifest/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/chunks/000001",
+				Size:      5,
+				ModTime:   modTime.Add(-time.Hour),
+				Sha256Hex: "6c87f68371b28954707ebb92afee7ccffb74c6f71ec8fea8a98cf6104289585b",
+			},
+			{
+				Path:      "manifest/01GHCE3ZV1VQ4M6F2XJ0BHZ4RA/meta.json",
+				Size:      72,
+				ModTime:   modTime,
+				Sha256Hex: "11d899f3e174d194166be7baeb443e77cb38d7d08afc946a14abe51b72e865b7",
+			},
+		},
+		Blocks: []api.ManifestBlock{
+			{ULID: "01GHCE3ZV1VQ4M6F2XJ0BHZ4RA", MinTime: 1000, MaxTime: 2000},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Manifest diff (-want +got):\n%s", diff)
+	}
+}
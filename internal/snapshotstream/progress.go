@@ -0,0 +1,150 @@
+package snapshotstream
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+// progress tracks the state of a running download. Counters are updated
+// atomically as they change for every read and write. All methods are safe to
+// call on a nil pointer.
+type progress struct {
+	bytesRead    atomic.Int64
+	bytesWritten atomic.Int64
+	filesDone    atomic.Int64
+
+	mu          sync.Mutex
+	startedAt   time.Time
+	bytesTotal  int64
+	filesTotal  int64
+	currentFile string
+}
+
+func newProgress(startedAt time.Time) *progress {
+	return &progress{
+		startedAt: startedAt,
+	}
+}
+
+// measure determines the number and total size of all regular files below
+// the root directory. Totals remain unknown (zero) on failure or when the
+// context is cancelled.
+func (p *progress) measure(ctx context.Context, root fs.FS) error {
+	if p == nil {
+		return nil
+	}
+
+	var files, bytes int64
+
+	if err := fs.WalkDir(root, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+
+		if d.Type().IsRegular() {
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			files++
+			bytes += fi.Size()
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.filesTotal = files
+	p.bytesTotal = bytes
+
+	return nil
+}
+
+func (p *progress) setCurrentFile(name string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.currentFile = name
+}
+
+func (p *progress) fileDone() {
+	if p != nil {
+		p.filesDone.Add(1)
+	}
+}
+
+func (p *progress) wrapReader(r io.ReadCloser) io.ReadCloser {
+	if p == nil {
+		return r
+	}
+
+	return &progressReader{r, &p.bytesRead}
+}
+
+func (p *progress) wrapWriter(w io.Writer) io.Writer {
+	if p == nil {
+		return w
+	}
+
+	return &progressWriter{w, &p.bytesWritten}
+}
+
+func (p *progress) status() *api.DownloadProgress {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return &api.DownloadProgress{
+		StartedAt:    p.startedAt,
+		BytesRead:    p.bytesRead.Load(),
+		BytesTotal:   p.bytesTotal,
+		BytesWritten: p.bytesWritten.Load(),
+		FilesDone:    p.filesDone.Load(),
+		FilesTotal:   p.filesTotal,
+		CurrentFile:  p.currentFile,
+	}
+}
+
+type progressReader struct {
+	io.ReadCloser
+	count *atomic.Int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
+
+type progressWriter struct {
+	w     io.Writer
+	count *atomic.Int64
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.count.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,93 @@
+package snapshotstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/prombackup/api"
+)
+
+func TestProgress(t *testing.T) {
+	startedAt := time.Date(2022, 11, 9, 20, 20, 35, 0, time.UTC)
+
+	p := newProgress(startedAt)
+
+	if err := p.measure(context.Background(), fstest.MapFS{
+		"a":          {Data: []byte("hello")},
+		"dir/b":      {Data: []byte("world!")},
+		"dir/subdir": {Mode: fs.ModeDir | 0o755},
+	}); err != nil {
+		t.Errorf("measure() failed: %v", err)
+	}
+
+	p.setCurrentFile("snap/a")
+
+	if _, err := io.Copy(p.wrapWriter(io.Discard), p.wrapReader(io.NopCloser(strings.NewReader("hello")))); err != nil {
+		t.Error(err)
+	}
+
+	p.fileDone()
+
+	want := &api.DownloadProgress{
+		StartedAt:    startedAt,
+		BytesRead:    5,
+		BytesTotal:   11,
+		BytesWritten: 5,
+		FilesDone:    1,
+		FilesTotal:   2,
+		CurrentFile:  "snap/a",
+	}
+
+	if diff := cmp.Diff(want, p.status()); diff != "" {
+		t.Errorf("status() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestProgressMeasureCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrCancelled)
+
+	p := newProgress(time.Time{})
+
+	if err := p.measure(ctx, fstest.MapFS{
+		"a": {Data: []byte("hello")},
+	}); !errors.Is(err, ErrCancelled) {
+		t.Errorf("measure() returned %v, want %v", err, ErrCancelled)
+	}
+
+	if got := p.status(); got.FilesTotal != 0 || got.BytesTotal != 0 {
+		t.Errorf("Totals set after cancellation: %+v", got)
+	}
+}
+
+func TestProgressNil(t *testing.T) {
+	var p *progress
+
+	if err := p.measure(context.Background(), fstest.MapFS{}); err != nil {
+		t.Errorf("measure() failed: %v", err)
+	}
+
+	p.setCurrentFile("test")
+	p.fileDone()
+
+	r := io.NopCloser(strings.NewReader(""))
+
+	if got := p.wrapReader(r); got != r {
+		t.Errorf("wrapReader() returned %v, want %v", got, r)
+	}
+
+	if got := p.wrapWriter(io.Discard); got != io.Discard {
+		t.Errorf("wrapWriter() returned %v, want %v", got, io.Discard)
+	}
+
+	if got := p.status(); got != nil {
+		t.Errorf("status() returned %v, want nil", got)
+	}
+}
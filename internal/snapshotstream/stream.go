@@ -1,6 +1,8 @@
 package snapshotstream
 
 import (
+	"context"
+	"crypto/ed25519"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -12,21 +14,55 @@ import (
 	"sync"
 	"time"
 
+	"filippo.io/age"
+	"github.com/dsnet/compress/bzip2"
 	"github.com/hansmi/prombackup/api"
-	"github.com/klauspost/compress/gzip"
+	"github.com/hansmi/prombackup/internal/digest"
 	"github.com/klauspost/compress/zstd"
-	"github.com/minio/sha256-simd"
+	"github.com/klauspost/pgzip"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
 	"go.uber.org/multierr"
 )
 
 var ErrArchiveFormat = errors.New("unknown archive format")
 var ErrNotFound = errors.New("snapshot not found")
 var ErrInvalid = errors.New("snapshot invalid")
+var ErrCancelled = errors.New("download cancelled")
+
+// ErrClientAborted is returned by WriteArchive when the caller's context was
+// cancelled or writing to the client failed, e.g. because the client
+// disconnected.
+var ErrClientAborted = errors.New("download aborted by client")
 
 type Options struct {
 	Name   string
 	Root   fs.FS
 	Format api.ArchiveFormat
+
+	// Compression settings. Not all formats support all settings.
+	Compression CompressionOptions
+
+	// The archive is encrypted to these recipients using age if any are
+	// given.
+	Recipients []age.Recipient
+
+	// Key for signing the SHA256 digest of successfully generated archives.
+	// Archives are not signed if nil.
+	SigningKey ed25519.PrivateKey
+
+	// Additional digest algorithms computed over the archive. SHA256 is
+	// always computed.
+	Digests []api.DigestAlgorithm
+
+	// Number of encoder goroutines used by formats supporting parallel
+	// compression if Compression.Concurrency is zero. Zero uses the encoder
+	// default.
+	DefaultConcurrency int
+
+	// Information about the requesting client, only used for reporting.
+	ClientAddress string
+	UserAgent     string
 }
 
 type Stream struct {
@@ -38,8 +74,17 @@ type Stream struct {
 	root   fs.FS
 	format api.ArchiveFormat
 
-	mu     sync.Mutex
-	status api.DownloadStatus
+	compression CompressionOptions
+	recipients  []age.Recipient
+	signingKey  ed25519.PrivateKey
+	digests     []api.DigestAlgorithm
+
+	mu       sync.Mutex
+	status   api.DownloadStatus
+	progress *progress
+
+	cancelRequested bool
+	cancel          context.CancelCauseFunc
 }
 
 func New(opts Options) (*Stream, error) {
@@ -52,18 +97,48 @@ func New(opts Options) (*Stream, error) {
 		name:   opts.Name,
 		root:   opts.Root,
 		format: opts.Format,
+
+		compression: opts.Compression,
+		recipients:  opts.Recipients,
+		signingKey:  opts.SigningKey,
+		digests:     append([]api.DigestAlgorithm{api.DigestSha256}, opts.Digests...),
+	}
+
+	if s.compression.Concurrency == 0 && supportsConcurrency(opts.Format) {
+		s.compression.Concurrency = opts.DefaultConcurrency
 	}
 
 	switch f := opts.Format; f {
-	case api.ArchiveTar, api.ArchiveTarGzip, api.ArchiveTarZstd:
+	case api.ArchiveTar, api.ArchiveTarGzip, api.ArchiveTarZstd,
+		api.ArchiveTarXz, api.ArchiveTarLz4, api.ArchiveTarBzip2, api.ArchiveZip:
 		s.ContentType = f.ContentType()
 		s.Filename = filepath.Base(s.name) + f.FileExtension()
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrArchiveFormat, f.Name())
 	}
 
+	if err := opts.Compression.validate(opts.Format); err != nil {
+		return nil, err
+	}
+
+	for _, a := range s.digests {
+		if _, err := digest.New(a); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.recipients) > 0 {
+		s.ContentType = api.EncryptedContentType
+		s.Filename += api.EncryptedFileExtension
+		s.status.Encrypted = true
+	}
+
 	s.status.ID = s.id
 	s.status.SnapshotName = s.name
+	s.status.Format = s.format
+	s.status.StartedAt = time.Now()
+	s.status.ClientAddress = opts.ClientAddress
+	s.status.UserAgent = opts.UserAgent
 
 	if fi, err := fs.Stat(s.root, "."); err != nil {
 		if os.IsNotExist(err) {
@@ -90,10 +165,34 @@ func (s *Stream) Status() api.DownloadStatus {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.status
+	status := s.status
+	status.Progress = s.progress.status()
+
+	return status
 }
 
-func (s *Stream) writeArchive(w io.Writer) (err error) {
+// Cancel stops generating the archive. A download not started yet fails
+// immediately once started. Returns false if the download has already
+// finished.
+func (s *Stream) Cancel() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.Finished != nil {
+		return false
+	}
+
+	s.cancelRequested = true
+
+	if s.cancel != nil {
+		s.cancel(ErrCancelled)
+	}
+
+	return true
+}
+
+func (s *Stream) writeArchive(ctx context.Context, w io.Writer, p *progress) (err error) {
+	var a archiver
 	var archiveWriter io.Writer
 	var compressionFlush func() error
 
@@ -102,8 +201,29 @@ func (s *Stream) writeArchive(w io.Writer) (err error) {
 		archiveWriter = w
 
 	case api.ArchiveTarGzip:
-		gzipWriter := gzip.NewWriter(w)
-		gzipWriter.Header.Name = strings.TrimSuffix(filepath.Base(s.Filename), filepath.Ext(s.Filename))
+		level := pgzip.DefaultCompression
+
+		if s.compression.Level != 0 {
+			level = s.compression.Level
+		}
+
+		// Blocks are compressed in parallel. The output is a single gzip
+		// member as produced by pigz.
+		var gzipWriter *pgzip.Writer
+
+		if gzipWriter, err = pgzip.NewWriterLevel(w, level); err != nil {
+			return err
+		}
+
+		if n := s.compression.Concurrency; n > 0 {
+			if err = gzipWriter.SetConcurrency(gzipBlockSize, n); err != nil {
+				return err
+			}
+		}
+
+		archiveName := strings.TrimSuffix(filepath.Base(s.Filename), api.EncryptedFileExtension)
+
+		gzipWriter.Header.Name = strings.TrimSuffix(archiveName, filepath.Ext(archiveName))
 		gzipWriter.Header.Comment = fmt.Sprintf("Prometheus snapshot %s", s.name)
 		gzipWriter.Header.ModTime = time.Now()
 
@@ -113,10 +233,9 @@ func (s *Stream) writeArchive(w io.Writer) (err error) {
 		compressionFlush = gzipWriter.Flush
 
 	case api.ArchiveTarZstd:
-		zstdWriter, err := zstd.NewWriter(w,
-			zstd.WithEncoderCRC(true),
-		)
-		if err != nil {
+		var zstdWriter *zstd.Encoder
+
+		if zstdWriter, err = zstd.NewWriter(w, s.compression.zstdOptions()...); err != nil {
 			return err
 		}
 
@@ -125,28 +244,169 @@ func (s *Stream) writeArchive(w io.Writer) (err error) {
 		archiveWriter = zstdWriter
 		compressionFlush = zstdWriter.Flush
 
+	case api.ArchiveTarXz:
+		// The xz writer doesn't support flushing.
+		var xzWriter *xz.Writer
+
+		if xzWriter, err = xz.NewWriter(w); err != nil {
+			return err
+		}
+
+		defer multierr.AppendInvoke(&err, multierr.Close(xzWriter))
+
+		archiveWriter = xzWriter
+
+	case api.ArchiveTarLz4:
+		lz4Writer := lz4.NewWriter(w)
+		lz4Options := []lz4.Option{lz4.ChecksumOption(true)}
+
+		if s.compression.Level != 0 {
+			lz4Options = append(lz4Options, lz4.CompressionLevelOption(lz4Levels[s.compression.Level-1]))
+		}
+
+		if err := lz4Writer.Apply(lz4Options...); err != nil {
+			return err
+		}
+
+		defer multierr.AppendInvoke(&err, multierr.Close(lz4Writer))
+
+		archiveWriter = lz4Writer
+		compressionFlush = lz4Writer.Flush
+
+	case api.ArchiveTarBzip2:
+		// The bzip2 writer doesn't support flushing.
+		var bzip2Writer *bzip2.Writer
+
+		if bzip2Writer, err = bzip2.NewWriter(w, &bzip2.WriterConfig{
+			Level: s.compression.Level,
+		}); err != nil {
+			return err
+		}
+
+		defer multierr.AppendInvoke(&err, multierr.Close(bzip2Writer))
+
+		archiveWriter = bzip2Writer
+
+	case api.ArchiveZip:
+		// Entries are compressed individually
+		a = newZipArchiver(w, s.compression.Level)
+
 	default:
 		return ErrArchiveFormat
 	}
 
-	a := newTarArchiver(archiveWriter, compressionFlush)
+	if a == nil {
+		a = newTarArchiver(archiveWriter, compressionFlush)
+	}
 
 	defer multierr.AppendInvoke(&err, multierr.Close(a))
 
-	return archiveDir(s.root, s.name, a)
+	manifest, err := archiveDir(ctx, s.root, s.name, a, p)
+	if err != nil {
+		return err
+	}
+
+	return appendManifest(ctx, a, s.name, manifest)
+}
+
+// writeEncrypted generates the archive and encrypts it if recipients are
+// configured.
+func (s *Stream) writeEncrypted(ctx context.Context, w io.Writer, p *progress) (err error) {
+	if len(s.recipients) == 0 {
+		return s.writeArchive(ctx, w, p)
+	}
+
+	var enc io.WriteCloser
+
+	if enc, err = age.Encrypt(w, s.recipients...); err != nil {
+		return err
+	}
+
+	defer multierr.AppendInvoke(&err, multierr.Close(enc))
+
+	return s.writeArchive(ctx, enc, p)
+}
+
+// clientWriter records the first error returned by the underlying writer.
+// Compressors may write from other goroutines.
+type clientWriter struct {
+	w io.Writer
+
+	mu  sync.Mutex
+	err error
 }
 
-func (s *Stream) WriteArchive(w io.Writer) error {
-	digestw := sha256.New()
+func (w *clientWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
 
-	err := s.writeArchive(io.MultiWriter(w, digestw))
+	if err != nil {
+		w.mu.Lock()
+		if w.err == nil {
+			w.err = err
+		}
+		w.mu.Unlock()
+	}
+
+	return n, err
+}
+
+func (w *clientWriter) failed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err != nil
+}
+
+// WriteArchive generates the snapshot archive. Generation stops when the
+// context is cancelled or Cancel is called.
+func (s *Stream) WriteArchive(parent context.Context, w io.Writer) error {
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	p := newProgress(time.Now())
+
+	s.mu.Lock()
+	s.progress = p
+	s.cancel = cancel
+
+	if s.cancelRequested {
+		cancel(ErrCancelled)
+	}
+	s.mu.Unlock()
+
+	// Totals are only informational. Cancellation is detected again when
+	// writing the archive.
+	_ = p.measure(ctx, s.root)
+
+	digests, err := digest.NewSet(s.digests...)
+	if err != nil {
+		return err
+	}
+
+	cw := &clientWriter{w: w}
+
+	err = s.writeEncrypted(ctx, p.wrapWriter(io.MultiWriter(cw, digests)), p)
 
 	sf := api.DownloadStatusFinished{
-		Success: (err == nil),
+		Success:   (err == nil),
+		Cancelled: (err != nil && errors.Is(context.Cause(ctx), ErrCancelled)),
+	}
+
+	// Write errors may be reported before the request context is cancelled.
+	if err != nil && !sf.Cancelled && (parent.Err() != nil || cw.failed()) {
+		sf.ClientAborted = true
+		err = fmt.Errorf("%w: %w", ErrClientAborted, err)
 	}
 
+	sf.FinishedAt = time.Now()
+
 	if err == nil {
-		sf.Sha256Hex = hex.EncodeToString(digestw.Sum(nil))
+		sf.Digests = digests.HexSums()
+		sf.Sha256Hex = sf.Digests[api.DigestSha256]
+
+		if s.signingKey != nil {
+			sf.SignatureHex = hex.EncodeToString(ed25519.Sign(s.signingKey, digests.Sum(api.DigestSha256)))
+		}
 	} else {
 		msg := err.Error()
 		sf.ErrorText = &msg
@@ -2,16 +2,28 @@ package snapshotstream
 
 import (
 	"bytes"
+	"compress/bzip2"
 	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
+	"syscall"
 	"testing"
 	"testing/fstest"
 
+	"filippo.io/age"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/hansmi/prombackup/api"
+	"github.com/hansmi/prombackup/internal/digest"
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
 )
 
 func TestStream(t *testing.T) {
@@ -38,12 +50,14 @@ func TestStream(t *testing.T) {
 			wantFilename:    "empty.tar",
 			wantStatusAfter: api.DownloadStatus{
 				SnapshotName: "empty",
+				Progress:     &api.DownloadProgress{},
 				Finished: &api.DownloadStatusFinished{
 					Success: true,
 				},
 			},
 			want: []tarEntry{
 				{name: "empty"},
+				{name: "empty/MANIFEST.json"},
 			},
 		},
 		{
@@ -64,6 +78,12 @@ func TestStream(t *testing.T) {
 			wantFilename:    "unsupported.tar",
 			wantStatusAfter: api.DownloadStatus{
 				SnapshotName: "unsupported",
+				Progress: &api.DownloadProgress{
+					BytesRead:  7,
+					BytesTotal: 7,
+					FilesDone:  1,
+					FilesTotal: 1,
+				},
 				Finished: &api.DownloadStatusFinished{
 					Success: false,
 				},
@@ -86,12 +106,20 @@ func TestStream(t *testing.T) {
 						Data: []byte("hello world"),
 					},
 				},
-				Format: api.ArchiveTarGzip,
+				Format:        api.ArchiveTarGzip,
+				ClientAddress: "192.0.2.1:1234",
+				UserAgent:     "test/1.0",
 			},
 			wantContentType: "application/gzip",
 			wantFilename:    "archive93c2.tar.gz",
 			wantStatusAfter: api.DownloadStatus{
 				SnapshotName: "archive93c2",
+				Progress: &api.DownloadProgress{
+					BytesRead:  11,
+					BytesTotal: 11,
+					FilesDone:  1,
+					FilesTotal: 1,
+				},
 				Finished: &api.DownloadStatusFinished{
 					Success: true,
 				},
@@ -103,6 +131,7 @@ func TestStream(t *testing.T) {
 			want: []tarEntry{
 				{name: "archive93c2"},
 				{name: "archive93c2/file", content: "hello world"},
+				{name: "archive93c2/MANIFEST.json"},
 			},
 		},
 		{
@@ -120,6 +149,12 @@ func TestStream(t *testing.T) {
 			wantFilename:    "archive51b2fb.tar.zst",
 			wantStatusAfter: api.DownloadStatus{
 				SnapshotName: "archive51b2fb",
+				Progress: &api.DownloadProgress{
+					BytesRead:  11,
+					BytesTotal: 11,
+					FilesDone:  1,
+					FilesTotal: 1,
+				},
 				Finished: &api.DownloadStatusFinished{
 					Success: true,
 				},
@@ -127,6 +162,131 @@ func TestStream(t *testing.T) {
 			want: []tarEntry{
 				{name: "archive51b2fb"},
 				{name: "archive51b2fb/file", content: "hello world"},
+				{name: "archive51b2fb/MANIFEST.json"},
+			},
+		},
+		{
+			name: "tar+xz",
+			opts: Options{
+				Name: "archivec7d1",
+				Root: &fstest.MapFS{
+					"file": {
+						Data: []byte("hello world"),
+					},
+				},
+				Format: api.ArchiveTarXz,
+			},
+			wantContentType: "application/x-xz",
+			wantFilename:    "archivec7d1.tar.xz",
+			wantStatusAfter: api.DownloadStatus{
+				SnapshotName: "archivec7d1",
+				Progress: &api.DownloadProgress{
+					BytesRead:  11,
+					BytesTotal: 11,
+					FilesDone:  1,
+					FilesTotal: 1,
+				},
+				Finished: &api.DownloadStatusFinished{
+					Success: true,
+				},
+			},
+			want: []tarEntry{
+				{name: "archivec7d1"},
+				{name: "archivec7d1/file", content: "hello world"},
+				{name: "archivec7d1/MANIFEST.json"},
+			},
+		},
+		{
+			name: "tar+lz4",
+			opts: Options{
+				Name: "archive04e9",
+				Root: &fstest.MapFS{
+					"file": {
+						Data: []byte("hello world"),
+					},
+				},
+				Format: api.ArchiveTarLz4,
+			},
+			wantContentType: "application/x-lz4",
+			wantFilename:    "archive04e9.tar.lz4",
+			wantStatusAfter: api.DownloadStatus{
+				SnapshotName: "archive04e9",
+				Progress: &api.DownloadProgress{
+					BytesRead:  11,
+					BytesTotal: 11,
+					FilesDone:  1,
+					FilesTotal: 1,
+				},
+				Finished: &api.DownloadStatusFinished{
+					Success: true,
+				},
+			},
+			want: []tarEntry{
+				{name: "archive04e9"},
+				{name: "archive04e9/file", content: "hello world"},
+				{name: "archive04e9/MANIFEST.json"},
+			},
+		},
+		{
+			name: "tar+bzip2",
+			opts: Options{
+				Name: "archive7f3a",
+				Root: &fstest.MapFS{
+					"file": {
+						Data: []byte("hello world"),
+					},
+				},
+				Format: api.ArchiveTarBzip2,
+			},
+			wantContentType: "application/x-bzip2",
+			wantFilename:    "archive7f3a.tar.bz2",
+			wantStatusAfter: api.DownloadStatus{
+				SnapshotName: "archive7f3a",
+				Progress: &api.DownloadProgress{
+					BytesRead:  11,
+					BytesTotal: 11,
+					FilesDone:  1,
+					FilesTotal: 1,
+				},
+				Finished: &api.DownloadStatusFinished{
+					Success: true,
+				},
+			},
+			want: []tarEntry{
+				{name: "archive7f3a"},
+				{name: "archive7f3a/file", content: "hello world"},
+				{name: "archive7f3a/MANIFEST.json"},
+			},
+		},
+		{
+			name: "zip",
+			opts: Options{
+				Name: "archive5e60",
+				Root: &fstest.MapFS{
+					"file": {
+						Data: []byte("hello world"),
+					},
+				},
+				Format: api.ArchiveZip,
+			},
+			wantContentType: "application/zip",
+			wantFilename:    "archive5e60.zip",
+			wantStatusAfter: api.DownloadStatus{
+				SnapshotName: "archive5e60",
+				Progress: &api.DownloadProgress{
+					BytesRead:  11,
+					BytesTotal: 11,
+					FilesDone:  1,
+					FilesTotal: 1,
+				},
+				Finished: &api.DownloadStatusFinished{
+					Success: true,
+				},
+			},
+			want: []tarEntry{
+				{name: "archive5e60"},
+				{name: "archive5e60/file", content: "hello world"},
+				{name: "archive5e60/MANIFEST.json"},
 			},
 		},
 	} {
@@ -147,12 +307,20 @@ func TestStream(t *testing.T) {
 
 			tc.wantStatusBefore.ID = s.ID()
 			tc.wantStatusBefore.SnapshotName = s.Name()
+			tc.wantStatusBefore.Format = tc.opts.Format
+			tc.wantStatusBefore.ClientAddress = tc.opts.ClientAddress
+			tc.wantStatusBefore.UserAgent = tc.opts.UserAgent
 			tc.wantStatusAfter.ID = tc.wantStatusBefore.ID
+			tc.wantStatusAfter.Format = tc.opts.Format
+			tc.wantStatusAfter.ClientAddress = tc.opts.ClientAddress
+			tc.wantStatusAfter.UserAgent = tc.opts.UserAgent
 
 			opts := []cmp.Option{
 				cmpopts.EquateErrors(),
 				cmpopts.EquateEmpty(),
-				cmpopts.IgnoreFields(api.DownloadStatusFinished{}, "ErrorText", "Sha256Hex"),
+				cmpopts.IgnoreFields(api.DownloadStatus{}, "StartedAt"),
+				cmpopts.IgnoreFields(api.DownloadStatusFinished{}, "ErrorText", "Sha256Hex", "Digests", "FinishedAt"),
+				cmpopts.IgnoreFields(api.DownloadProgress{}, "StartedAt", "BytesWritten"),
 			}
 
 			if diff := cmp.Diff(tc.wantStatusBefore, s.Status(), opts...); diff != "" {
@@ -161,7 +329,7 @@ func TestStream(t *testing.T) {
 
 			var buf bytes.Buffer
 
-			err = s.WriteArchive(&buf)
+			err = s.WriteArchive(context.Background(), &buf)
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -171,6 +339,19 @@ func TestStream(t *testing.T) {
 				t.Errorf("Status() diff (-want +got):\n%s", diff)
 			}
 
+			if got := s.Status().Progress.BytesWritten; got != int64(buf.Len()) {
+				t.Errorf("Progress reports %d bytes written, want %d", got, buf.Len())
+			}
+
+			if status := s.Status(); status.Finished.FinishedAt.Before(status.StartedAt) {
+				t.Errorf("Download finished at %v, before start at %v", status.Finished.FinishedAt, status.StartedAt)
+			}
+
+			if tc.opts.Format == api.ArchiveZip {
+				checkZipContents(t, buf.Bytes(), tc.want)
+				return
+			}
+
 			var tarReader io.Reader = &buf
 
 			switch tc.opts.Format {
@@ -195,6 +376,20 @@ func TestStream(t *testing.T) {
 
 				tarReader = zstdReader
 
+			case api.ArchiveTarXz:
+				xzReader, err := xz.NewReader(tarReader)
+				if err != nil {
+					t.Errorf("xz.NewReader() failed: %v", err)
+				}
+
+				tarReader = xzReader
+
+			case api.ArchiveTarLz4:
+				tarReader = lz4.NewReader(tarReader)
+
+			case api.ArchiveTarBzip2:
+				tarReader = bzip2.NewReader(tarReader)
+
 			default:
 				t.Errorf("Unhandled format %q", tc.opts.Format)
 			}
@@ -203,3 +398,303 @@ func TestStream(t *testing.T) {
 		})
 	}
 }
+
+type cancellingWriter struct {
+	s *Stream
+}
+
+func (w *cancellingWriter) Write(p []byte) (int, error) {
+	w.s.Cancel()
+
+	return len(p), nil
+}
+
+func TestStreamCancel(t *testing.T) {
+	root := fstest.MapFS{
+		"a": {Data: []byte("aaa")},
+		"b": {Data: []byte("bbb")},
+		"c": {Data: []byte("ccc")},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		before        bool
+		during        bool
+		wantErr       error
+		wantCancelled bool
+	}{
+		{name: "not cancelled"},
+		{
+			name:          "before start",
+			before:        true,
+			wantErr:       ErrCancelled,
+			wantCancelled: true,
+		},
+		{
+			name:          "during",
+			during:        true,
+			wantErr:       ErrCancelled,
+			wantCancelled: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(Options{
+				Name:   "cancel",
+				Root:   root,
+				Format: api.ArchiveTar,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.before && !s.Cancel() {
+				t.Errorf("Cancel() before start returned false")
+			}
+
+			var w io.Writer = io.Discard
+
+			if tc.during {
+				w = &cancellingWriter{s}
+			}
+
+			err = s.WriteArchive(context.Background(), w)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			sf := s.Status().Finished
+
+			if sf.Success != (tc.wantErr == nil) || sf.Cancelled != tc.wantCancelled {
+				t.Errorf("Finished status %+v, want cancelled=%t", sf, tc.wantCancelled)
+			}
+
+			if s.Cancel() {
+				t.Errorf("Cancel() after finishing returned true")
+			}
+		})
+	}
+}
+
+type abortingWriter struct {
+	cancel context.CancelFunc
+}
+
+func (w *abortingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+
+	return len(p), nil
+}
+
+func TestStreamClientAborted(t *testing.T) {
+	s, err := New(Options{
+		Name: "aborted",
+		Root: fstest.MapFS{
+			"a": {Data: []byte("aaa")},
+			"b": {Data: []byte("bbb")},
+		},
+		Format: api.ArchiveTar,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = s.WriteArchive(ctx, &abortingWriter{cancel})
+
+	if !(errors.Is(err, ErrClientAborted) && errors.Is(err, context.Canceled)) {
+		t.Errorf("WriteArchive() returned %v, want %v", err, ErrClientAborted)
+	}
+
+	sf := s.Status().Finished
+
+	if sf.Success || sf.Cancelled || !sf.ClientAborted {
+		t.Errorf("Finished status %+v, want client_aborted=true", sf)
+	}
+
+	if sf.ErrorText == nil {
+		t.Errorf("Error text missing")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, syscall.EPIPE
+}
+
+func TestStreamClientWriteFailed(t *testing.T) {
+	s, err := New(Options{
+		Name: "aborted",
+		Root: fstest.MapFS{
+			"a": {Data: []byte("aaa")},
+		},
+		Format: api.ArchiveTar,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.WriteArchive(context.Background(), failingWriter{})
+
+	if !(errors.Is(err, ErrClientAborted) && errors.Is(err, syscall.EPIPE)) {
+		t.Errorf("WriteArchive() returned %v, want %v", err, ErrClientAborted)
+	}
+
+	if sf := s.Status().Finished; sf.Success || sf.Cancelled || !sf.ClientAborted {
+		t.Errorf("Finished status %+v, want client_aborted=true", sf)
+	}
+}
+
+func TestStreamEncrypted(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(Options{
+		Name: "secret",
+		Root: fstest.MapFS{
+			"file": {Data: []byte("hello world")},
+		},
+		Format:     api.ArchiveTarGzip,
+		Recipients: []age.Recipient{identity.Recipient()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff("secret.tar.gz.age", s.Filename); diff != "" {
+		t.Errorf("Filename diff (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(api.EncryptedContentType, s.ContentType); diff != "" {
+		t.Errorf("Content type diff (-want +got):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+
+	if err := s.WriteArchive(context.Background(), &buf); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	status := s.Status()
+
+	if !status.Encrypted {
+		t.Errorf("Status doesn't report encryption: %+v", status)
+	}
+
+	if got, want := status.Finished.Sha256Hex, fmt.Sprintf("%x", sha256.Sum256(buf.Bytes())); got != want {
+		t.Errorf("Checksum is %s, want %s computed over ciphertext", got, want)
+	}
+
+	dr, err := age.Decrypt(&buf, identity)
+	if err != nil {
+		t.Fatalf("Decrypt() failed: %v", err)
+	}
+
+	gzReader, err := gzip.NewReader(dr)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() failed: %v", err)
+	}
+
+	if diff := cmp.Diff("secret.tar", gzReader.Header.Name); diff != "" {
+		t.Errorf("Gzip header name diff (-want +got):\n%s", diff)
+	}
+
+	checkTarContents(t, gzReader, []tarEntry{
+		{name: "secret"},
+		{name: "secret/file", content: "hello world"},
+		{name: "secret/MANIFEST.json"},
+	})
+}
+
+func TestStreamSigned(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(Options{
+		Name:       "signed",
+		Root:       fstest.MapFS{"file": {Data: []byte("content")}},
+		Format:     api.ArchiveTar,
+		SigningKey: privateKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := s.WriteArchive(context.Background(), &buf); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	digest := sha256.Sum256(buf.Bytes())
+
+	signature, err := hex.DecodeString(s.Status().Finished.SignatureHex)
+	if err != nil {
+		t.Fatalf("Decoding signature failed: %v", err)
+	}
+
+	if !ed25519.Verify(publicKey, digest[:], signature) {
+		t.Errorf("Signature %x is not valid", signature)
+	}
+}
+
+func TestStreamDigests(t *testing.T) {
+	s, err := New(Options{
+		Name:    "digests",
+		Root:    fstest.MapFS{"file": {Data: []byte("content")}},
+		Format:  api.ArchiveTar,
+		Digests: []api.DigestAlgorithm{api.DigestBlake3, api.DigestSha512},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := s.WriteArchive(context.Background(), &buf); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	want := map[api.DigestAlgorithm]string{}
+
+	for _, a := range api.DigestAlgorithmAll {
+		h, err := digest.New(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h.Write(buf.Bytes())
+
+		want[a] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	sf := s.Status().Finished
+
+	if diff := cmp.Diff(want, sf.Digests); diff != "" {
+		t.Errorf("Digests diff (-want +got):\n%s", diff)
+	}
+
+	if sf.Sha256Hex != want[api.DigestSha256] {
+		t.Errorf("Sha256Hex = %q, want %q", sf.Sha256Hex, want[api.DigestSha256])
+	}
+}
+
+func TestNewUnknownDigest(t *testing.T) {
+	_, err := New(Options{
+		Name:    "digests",
+		Root:    fstest.MapFS{},
+		Format:  api.ArchiveTar,
+		Digests: []api.DigestAlgorithm{"md5"},
+	})
+
+	if !errors.Is(err, digest.ErrUnknownAlgorithm) {
+		t.Errorf("New() error = %v, want %v", err, digest.ErrUnknownAlgorithm)
+	}
+}
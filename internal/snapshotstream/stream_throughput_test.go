@@ -2,6 +2,7 @@ package snapshotstream
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"fmt"
 	"io/fs"
@@ -55,7 +56,7 @@ func BenchmarkStreamWriteArchive(b *testing.B) {
 					b.ResetTimer()
 
 					for i := 0; i < b.N; i++ {
-						if err := s.WriteArchive(&output); err != nil {
+						if err := s.WriteArchive(context.Background(), &output); err != nil {
 							b.Errorf("WriteArchive() failed: %v", err)
 						}
 
@@ -2,6 +2,7 @@ package snapshotstream
 
 import (
 	"archive/tar"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -42,8 +43,9 @@ func (a *tarArchiver) FileErrors() error {
 
 // Append writes meta information and file content to the archive. Globally
 // fatal errors are returned straight away while per-file errors are collected.
-// Directories and regular files are the only supported types.
-func (a *tarArchiver) Append(name string, d fs.DirEntry, open openFunc) (err error) {
+// Directories and regular files are the only supported types. Copying file
+// content stops when the context is cancelled.
+func (a *tarArchiver) Append(ctx context.Context, name string, d fs.DirEntry, open openFunc) (err error) {
 	hdr := tar.Header{
 		Name: filepath.ToSlash(filepath.Clean(name)),
 	}
@@ -92,7 +94,7 @@ func (a *tarArchiver) Append(name string, d fs.DirEntry, open openFunc) (err err
 
 		defer multierr.AppendInvoke(&err, multierr.Close(fh))
 
-		_, err = io.CopyBuffer(a.tw, fh, a.copybuf)
+		_, err = io.CopyBuffer(a.tw, contextReader{ctx, fh}, a.copybuf)
 
 		return err
 	}
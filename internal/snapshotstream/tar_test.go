@@ -3,14 +3,18 @@ package snapshotstream
 import (
 	"archive/tar"
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"io/fs"
+	"path"
 	"testing"
 	"testing/fstest"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
 )
 
 var errTest = errors.New("test error")
@@ -20,6 +24,27 @@ type tarEntry struct {
 	content string
 }
 
+// newTarEntry returns an entry for comparisons. The content of manifests is
+// only checked for validity as it depends on file modification times.
+func newTarEntry(t *testing.T, name string, content []byte) tarEntry {
+	t.Helper()
+
+	if path.Base(name) == api.ManifestName {
+		var m api.Manifest
+
+		if err := json.Unmarshal(content, &m); err != nil {
+			t.Errorf("Unmarshal(%q) failed: %v", name, err)
+		}
+
+		content = nil
+	}
+
+	return tarEntry{
+		name:    name,
+		content: string(content),
+	}
+}
+
 func checkTarContents(t *testing.T, r io.Reader, want []tarEntry) {
 	var got []tarEntry
 
@@ -33,17 +58,12 @@ func checkTarContents(t *testing.T, r io.Reader, want []tarEntry) {
 			t.Errorf("Next() failed: %v", err)
 		}
 
-		e := tarEntry{
-			name: hdr.Name,
-		}
-
-		if content, err := io.ReadAll(tr); err != nil {
+		content, err := io.ReadAll(tr)
+		if err != nil {
 			t.Errorf("ReadAll() failed: %v", err)
-		} else {
-			e.content = string(content)
 		}
 
-		got = append(got, e)
+		got = append(got, newTarEntry(t, hdr.Name, content))
 	}
 
 	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty(), cmp.AllowUnexported(tarEntry{})); diff != "" {
@@ -186,7 +206,7 @@ func TestTarArchiverLarge(t *testing.T) {
 				return tc.flushErr
 			})
 
-			err := archiveDir(tc.root, ".", a)
+			_, err := archiveDir(context.Background(), tc.root, ".", a, nil)
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("archiveDir() error diff (-want +got):\n%s", diff)
@@ -198,3 +218,23 @@ func TestTarArchiverLarge(t *testing.T) {
 		})
 	}
 }
+
+func TestTarArchiverCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errTest)
+
+	e := archiveEntry{
+		name: "file",
+		data: "content",
+	}
+
+	d, open := e.args(t)
+
+	var buf bytes.Buffer
+
+	a := newTarArchiver(&buf, nil)
+
+	if diff := cmp.Diff(errTest, a.Append(ctx, e.name, d, open), cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("Append() error diff (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,97 @@
+package snapshotstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/zip"
+	"go.uber.org/multierr"
+)
+
+type zipArchiver struct {
+	zw      *zip.Writer
+	copybuf []byte
+	fileErr error
+}
+
+// newZipArchiver returns an archiver writing a ZIP file. Entry sizes and
+// checksums are stored in data descriptors following the content, so the
+// output can be streamed without seeking. A non-zero level overrides the
+// default Deflate compression level.
+func newZipArchiver(w io.Writer, level int) *zipArchiver {
+	zw := zip.NewWriter(w)
+
+	if level != 0 {
+		zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		})
+	}
+
+	return &zipArchiver{
+		zw:      zw,
+		copybuf: make([]byte, 1024*1024),
+	}
+}
+
+func (a *zipArchiver) Close() error {
+	return a.zw.Close()
+}
+
+func (a *zipArchiver) FileErrors() error {
+	return a.fileErr
+}
+
+// Append writes meta information and file content to the archive. Globally
+// fatal errors are returned straight away while per-file errors are collected.
+// Directories and regular files are the only supported types. File content is
+// compressed using Deflate, the method supported by virtually all ZIP readers.
+func (a *zipArchiver) Append(ctx context.Context, name string, d fs.DirEntry, open openFunc) (err error) {
+	hdr := zip.FileHeader{
+		Name: filepath.ToSlash(filepath.Clean(name)),
+	}
+
+	if d.IsDir() {
+		hdr.Name += "/"
+		hdr.Method = zip.Store
+		hdr.SetMode(fs.ModeDir | 0o755)
+	} else if d.Type().IsRegular() {
+		hdr.Method = zip.Deflate
+		hdr.SetMode(0o644)
+	} else {
+		multierr.AppendInto(&a.fileErr, fmt.Errorf("%w: %s (%s)", errUnsupportedType, name, d.Type()))
+		return nil
+	}
+
+	fi, err := d.Info()
+	if err != nil {
+		multierr.AppendInto(&a.fileErr, fmt.Errorf("%s: %w", name, err))
+		return nil
+	}
+
+	hdr.Modified = fi.ModTime().UTC()
+
+	fw, err := a.zw.CreateHeader(&hdr)
+	if err != nil {
+		return err
+	}
+
+	if d.Type().IsRegular() {
+		var fh io.ReadCloser
+
+		if fh, err = open(); err != nil {
+			return err
+		}
+
+		defer multierr.AppendInvoke(&err, multierr.Close(fh))
+
+		_, err = io.CopyBuffer(fw, contextReader{ctx, fh}, a.copybuf)
+
+		return err
+	}
+
+	return nil
+}
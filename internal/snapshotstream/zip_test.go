@@ -0,0 +1,157 @@
+package snapshotstream
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/klauspost/compress/zip"
+)
+
+func readZip(t *testing.T, data []byte) *zip.Reader {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() failed: %v", err)
+	}
+
+	return zr
+}
+
+func checkZipContents(t *testing.T, data []byte, want []tarEntry) {
+	t.Helper()
+
+	var got []tarEntry
+
+	for _, f := range readZip(t, data).File {
+		var content []byte
+
+		if fh, err := f.Open(); err != nil {
+			t.Errorf("Open(%q) failed: %v", f.Name, err)
+		} else {
+			if content, err = io.ReadAll(fh); err != nil {
+				t.Errorf("ReadAll() failed: %v", err)
+			}
+
+			fh.Close()
+		}
+
+		got = append(got, newTarEntry(t, strings.TrimSuffix(f.Name, "/"), content))
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty(), cmp.AllowUnexported(tarEntry{})); diff != "" {
+		t.Errorf("Archive contents diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestZipArchiver(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		entries        []archiveEntry
+		wantFileErrors error
+		want           []tarEntry
+	}{
+		{name: "empty"},
+		{
+			name: "mixed",
+			entries: []archiveEntry{
+				{
+					name: "test.txt",
+					data: "test content",
+				},
+				{
+					name: "dir",
+					mode: fs.ModeDir,
+				},
+				{
+					name: "dir/hello.txt",
+					data: "world",
+				},
+			},
+			want: []tarEntry{
+				{
+					name:    "test.txt",
+					content: "test content",
+				},
+				{name: "dir"},
+				{
+					name:    "dir/hello.txt",
+					content: "world",
+				},
+			},
+		},
+		{
+			name: "unsupported socket",
+			entries: []archiveEntry{
+				{
+					name: "socket",
+					mode: fs.ModeSocket,
+				},
+			},
+			wantFileErrors: errUnsupportedType,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			archiverTest{
+				entries:        tc.entries,
+				wantFileErrors: tc.wantFileErrors,
+			}.do(t, newZipArchiver(&buf, 0))
+
+			checkZipContents(t, buf.Bytes(), tc.want)
+		})
+	}
+}
+
+func TestZipArchiverHeaders(t *testing.T) {
+	modTime := time.Date(2022, 11, 9, 20, 20, 35, 0, time.UTC)
+
+	var buf bytes.Buffer
+
+	archiverTest{
+		entries: []archiveEntry{
+			{name: "dir", mode: fs.ModeDir, modTime: modTime},
+			{name: "dir/file", data: "content", modTime: modTime.Add(time.Hour)},
+		},
+	}.do(t, newZipArchiver(&buf, 0))
+
+	files := readZip(t, buf.Bytes()).File
+
+	if len(files) != 2 {
+		t.Fatalf("Archive has %d entries, want 2", len(files))
+	}
+
+	for idx, want := range []struct {
+		method  uint16
+		mode    fs.FileMode
+		modTime time.Time
+	}{
+		{zip.Store, fs.ModeDir | 0o755, modTime},
+		{zip.Deflate, 0o644, modTime.Add(time.Hour)},
+	} {
+		f := files[idx]
+
+		if f.Method != want.method {
+			t.Errorf("%s: method %d, want %d", f.Name, f.Method, want.method)
+		}
+
+		if got := f.Mode(); got != want.mode {
+			t.Errorf("%s: mode %v, want %v", f.Name, got, want.mode)
+		}
+
+		if !f.Modified.Equal(want.modTime) {
+			t.Errorf("%s: modified %v, want %v", f.Name, f.Modified, want.modTime)
+		}
+
+		if f.Flags&0x8 == 0 && f.Method != zip.Store {
+			t.Errorf("%s: entry doesn't use a data descriptor", f.Name)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package statusstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/hansmi/prombackup/api"
+	"go.uber.org/multierr"
+)
+
+// Rewrite the file once it contains this many more lines than live records.
+const compactThreshold = 1000
+
+// Lines longer than this are skipped when loading.
+const maxLineSize = 1024 * 1024
+
+// fileStore keeps records in memory and appends every change to a file
+// containing one JSON-encoded record per line. The file is compacted when
+// opened and whenever it has grown sufficiently.
+type fileStore struct {
+	mem  *memoryStore
+	path string
+
+	mu    sync.Mutex
+	file  *os.File
+	lines int
+}
+
+var _ Store = (*fileStore)(nil)
+
+// OpenFile returns a store persisting records to the file at the given path.
+// The file is created if it doesn't exist.
+func OpenFile(path string, opts Options) (Store, error) {
+	s := &fileStore{
+		mem:  newMemoryStore(opts),
+		path: path,
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *fileStore) load() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+
+	for lineno := 1; ; lineno++ {
+		line, err := readLine(br, maxLineSize)
+		if err == io.EOF {
+			return nil
+		} else if errors.Is(err, errLineTooLong) {
+			s.mem.opts.logf("Skipping download status record at %s:%d: %v", s.path, lineno, err)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		var r record
+
+		if err := json.Unmarshal(line, &r); err != nil {
+			// An incomplete last line may remain after a crash and other
+			// lines may be damaged. Losing a few records is preferable over
+			// refusing to start. Compaction discards them.
+			s.mem.opts.logf("Skipping corrupt download status record at %s:%d: %v", s.path, lineno, err)
+			continue
+		}
+
+		s.mem.put(r)
+	}
+}
+
+var errLineTooLong = errors.New("line too long")
+
+// readLine returns the next line without the trailing newline. The remainder
+// of lines longer than the given limit is consumed and errLineTooLong is
+// returned. An incomplete last line is returned as-is.
+func readLine(br *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+
+	tooLong := false
+
+	for {
+		chunk, err := br.ReadSlice('\n')
+
+		if !tooLong {
+			if len(line)+len(chunk) > limit+1 {
+				tooLong = true
+				line = nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case err == io.EOF && (len(line) > 0 || tooLong):
+		case err != nil:
+			return nil, err
+		}
+
+		if tooLong {
+			return nil, errLineTooLong
+		}
+
+		return bytes.TrimSuffix(line, []byte("\n")), nil
+	}
+}
+
+// compact replaces the file with one containing only live records. The caller
+// must either hold the mutex or have exclusive access.
+func (s *fileStore) compact() (err error) {
+	records := s.mem.snapshot()
+
+	sort.Slice(records, func(a, b int) bool {
+		return records[a].Stored.Before(records[b].Stored)
+	})
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	bw := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(bw)
+
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+
+	// Some platforms don't support replacing open files.
+	reopen := s.file != nil
+
+	if reopen {
+		s.file.Close()
+		s.file = nil
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		// Keep appending to the existing file. Compaction is retried on the
+		// next write.
+		if reopen {
+			if f, openErr := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0); openErr == nil {
+				s.file = f
+			} else {
+				err = multierr.Append(err, openErr)
+			}
+		}
+
+		return err
+	}
+
+	s.file = tmp
+	s.lines = len(records)
+
+	return nil
+}
+
+func (s *fileStore) Put(status api.DownloadStatus) error {
+	r := record{
+		Stored: s.mem.opts.now(),
+		Status: status,
+	}
+
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	s.mem.put(r)
+
+	if _, err := s.file.Write(append(buf, '\n')); err != nil {
+		return err
+	}
+
+	s.lines++
+
+	if s.lines-s.mem.len() > compactThreshold {
+		return s.compact()
+	}
+
+	return s.file.Sync()
+}
+
+func (s *fileStore) Get(id string) (*api.DownloadStatus, error) {
+	return s.mem.Get(id)
+}
+
+func (s *fileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
@@ -0,0 +1,198 @@
+package statusstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.Count(string(content), "\n")
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.jsonl")
+
+	clock := fakeClock{
+		now: time.Date(2022, 11, 9, 20, 0, 0, 0, time.UTC),
+	}
+
+	opts := Options{
+		Retention: time.Hour,
+		nowFunc:   clock.Now,
+	}
+
+	first := api.DownloadStatus{
+		ID:           "first",
+		SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+		Finished: &api.DownloadStatusFinished{
+			Success:   true,
+			Sha256Hex: "e3b0c442",
+		},
+	}
+	second := api.DownloadStatus{
+		ID:           "second",
+		SnapshotName: first.SnapshotName,
+	}
+
+	s, err := OpenFile(path, opts)
+	if err != nil {
+		t.Fatalf("OpenFile() failed: %v", err)
+	}
+
+	checkGet(t, s, "first", nil)
+
+	if err := s.Put(first); err != nil {
+		t.Errorf("Put() failed: %v", err)
+	}
+
+	clock.now = clock.now.Add(30 * time.Minute)
+
+	if err := s.Put(second); err != nil {
+		t.Errorf("Put() failed: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+
+	if err := s.Put(second); err == nil {
+		t.Errorf("Put() after Close() succeeded")
+	}
+
+	// Reopen with all records retained
+	s, err = OpenFile(path, opts)
+	if err != nil {
+		t.Fatalf("OpenFile() failed: %v", err)
+	}
+
+	checkGet(t, s, "first", &first)
+	checkGet(t, s, "second", &second)
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+
+	// Simulate an incomplete write
+	if f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0); err != nil {
+		t.Fatal(err)
+	} else {
+		f.WriteString(`{"stored":"2022-11-`)
+		f.Close()
+	}
+
+	// Reopen after the first record expired
+	clock.now = clock.now.Add(45 * time.Minute)
+
+	s, err = OpenFile(path, opts)
+	if err != nil {
+		t.Fatalf("OpenFile() failed: %v", err)
+	}
+
+	checkGet(t, s, "first", nil)
+	checkGet(t, s, "second", &second)
+
+	if got := countLines(t, path); got != 1 {
+		t.Errorf("File has %d lines after compaction, want 1", got)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+}
+
+func TestFileCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.jsonl")
+
+	first := api.DownloadStatus{ID: "first"}
+	second := api.DownloadStatus{ID: "second"}
+
+	var content bytes.Buffer
+
+	for _, status := range []api.DownloadStatus{first, second} {
+		buf, err := json.Marshal(record{Status: status})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content.Write(buf)
+		content.WriteString("\nnot json\n")
+
+		if status.ID == first.ID {
+			content.WriteString(`{"status":{"id":"` + strings.Repeat("x", 2*maxLineSize) + `"}}` + "\n")
+		}
+	}
+
+	content.WriteString(`{"status":{"id":"trunc`)
+
+	if err := os.WriteFile(path, content.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs strings.Builder
+
+	s, err := OpenFile(path, Options{
+		Logger: log.New(&logs, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("OpenFile() failed: %v", err)
+	}
+
+	checkGet(t, s, "first", &first)
+	checkGet(t, s, "second", &second)
+
+	for _, lineno := range []string{":2:", ":3:", ":5:", ":6:"} {
+		if !strings.Contains(logs.String(), lineno) {
+			t.Errorf("Log doesn't mention line %s:\n%s", lineno, logs.String())
+		}
+	}
+
+	if got := countLines(t, path); got != 2 {
+		t.Errorf("File has %d lines after compaction, want 2", got)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	br := bufio.NewReaderSize(strings.NewReader("short\n"+strings.Repeat("x", 100)+"\n\nexact\nlast"), 16)
+
+	for _, want := range []struct {
+		line string
+		err  error
+	}{
+		{line: "short"},
+		{err: errLineTooLong},
+		{line: ""},
+		{line: "exact"},
+		{line: "last"},
+		{err: io.EOF},
+	} {
+		line, err := readLine(br, 5)
+
+		if !errors.Is(err, want.err) {
+			t.Errorf("readLine() returned error %v, want %v", err, want.err)
+		} else if string(line) != want.line {
+			t.Errorf("readLine() returned %q, want %q", line, want.line)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package statusstore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+type memoryStore struct {
+	opts Options
+
+	mu      sync.Mutex
+	records map[string]record
+}
+
+var _ Store = (*memoryStore)(nil)
+
+// NewMemory returns a store keeping all records in memory.
+func NewMemory(opts Options) Store {
+	return newMemoryStore(opts)
+}
+
+func newMemoryStore(opts Options) *memoryStore {
+	return &memoryStore{
+		opts:    opts,
+		records: map[string]record{},
+	}
+}
+
+// expireLocked removes all expired records. The caller must hold the mutex.
+func (s *memoryStore) expireLocked() {
+	for id, r := range s.records {
+		if s.opts.expired(r) {
+			delete(s.records, id)
+		}
+	}
+}
+
+func (s *memoryStore) put(r record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked()
+
+	if !s.opts.expired(r) {
+		s.records[r.Status.ID] = r
+	}
+}
+
+func (s *memoryStore) Put(status api.DownloadStatus) error {
+	s.put(record{
+		Stored: s.opts.now(),
+		Status: status,
+	})
+
+	return nil
+}
+
+func (s *memoryStore) Get(id string) (*api.DownloadStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked()
+
+	r, ok := s.records[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	return &r.Status, nil
+}
+
+// snapshot returns all current records.
+func (s *memoryStore) snapshot() []record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked()
+
+	result := make([]record, 0, len(s.records))
+
+	for _, r := range s.records {
+		result = append(result, r)
+	}
+
+	return result
+}
+
+func (s *memoryStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.records)
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
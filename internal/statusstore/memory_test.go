@@ -0,0 +1,89 @@
+package statusstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prombackup/api"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func checkGet(t *testing.T, s Store, id string, want *api.DownloadStatus) {
+	t.Helper()
+
+	var wantErr error
+
+	if want == nil {
+		wantErr = ErrNotFound
+	}
+
+	got, err := s.Get(id)
+
+	if diff := cmp.Diff(wantErr, err, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("Get(%q) error diff (-want +got):\n%s", id, diff)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Get(%q) diff (-want +got):\n%s", id, diff)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	clock := fakeClock{
+		now: time.Date(2022, 11, 9, 20, 0, 0, 0, time.UTC),
+	}
+
+	s := NewMemory(Options{
+		Retention: time.Hour,
+		nowFunc:   clock.Now,
+	})
+
+	first := api.DownloadStatus{
+		ID:           "first",
+		SnapshotName: "20221109T202035Z-355a5b4970d5a906",
+		Finished: &api.DownloadStatusFinished{
+			Success:   true,
+			Sha256Hex: "e3b0c442",
+		},
+	}
+
+	checkGet(t, s, "first", nil)
+
+	if err := s.Put(first); err != nil {
+		t.Errorf("Put() failed: %v", err)
+	}
+
+	checkGet(t, s, "first", &first)
+
+	clock.now = clock.now.Add(30 * time.Minute)
+
+	second := api.DownloadStatus{
+		ID:           "second",
+		SnapshotName: first.SnapshotName,
+	}
+
+	if err := s.Put(second); err != nil {
+		t.Errorf("Put() failed: %v", err)
+	}
+
+	checkGet(t, s, "first", &first)
+	checkGet(t, s, "second", &second)
+
+	clock.now = clock.now.Add(30 * time.Minute)
+
+	checkGet(t, s, "first", nil)
+	checkGet(t, s, "second", &second)
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Package statusstore keeps the status of finished downloads so that clients
+// can verify their download after it's no longer tracked by the server, e.g.
+// after a restart.
+package statusstore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hansmi/prombackup/api"
+)
+
+var ErrNotFound = errors.New("download status not found")
+
+type Logger interface {
+	Print(...any)
+	Printf(string, ...any)
+}
+
+type Store interface {
+	// Put stores the status of a download, replacing any previous record
+	// with the same ID.
+	Put(api.DownloadStatus) error
+
+	// Get returns the status of a download or ErrNotFound.
+	Get(id string) (*api.DownloadStatus, error)
+
+	Close() error
+}
+
+type Options struct {
+	// Records are discarded after this amount of time. Zero keeps records
+	// indefinitely.
+	Retention time.Duration
+
+	// Logger receives messages about skipped records. Nil discards them.
+	Logger Logger
+
+	nowFunc func() time.Time
+}
+
+func (o *Options) logf(format string, args ...any) {
+	if o.Logger != nil {
+		o.Logger.Printf(format, args...)
+	}
+}
+
+func (o *Options) now() time.Time {
+	if o.nowFunc == nil {
+		return time.Now()
+	}
+
+	return o.nowFunc()
+}
+
+func (o *Options) expired(r record) bool {
+	return o.Retention > 0 && !o.now().Before(r.Stored.Add(o.Retention))
+}
+
+// record is the unit of storage. It's also the format of the lines in the
+// file-based store.
+type record struct {
+	Stored time.Time          `json:"stored"`
+	Status api.DownloadStatus `json:"status"`
+}